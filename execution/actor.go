@@ -0,0 +1,161 @@
+package execution
+
+import (
+	"errors"
+	"runtime/debug"
+)
+
+// ErrActorStopped is returned when a message is sent to an Actor that has
+// stopped.
+var ErrActorStopped = errors.New("Actor stopped!")
+
+// An Actor processes the messages of its mailbox one at a time, in its own
+// goroutine, under its own Context. The state of the actor lives in the
+// Storer of that Context, which is never shared: no other goroutine ever
+// touches it, so that it needs no synchronization.
+//
+// The Context of an Actor is spawned from the Context of its parent, be it
+// another actor: cancelling a parent stops every actor spawned from it.
+//
+// An actor stops when its Controller is cancelled, or when its handler
+// returns an error or panics. Wait returns the reason it stopped.
+type Actor[M, R any] struct {
+	ctx     Context
+	handler func(Context, M) (R, error)
+	mailbox chan envelope[M, R]
+	done    chan struct{}
+	err     error
+}
+
+// envelope carries a message. A message sent via Ask carries the Controller
+// of the caller and a reply channel.
+type envelope[M, R any] struct {
+	msg    M
+	caller Controller
+	reply  chan reply[R]
+}
+
+type reply[R any] struct {
+	v   R
+	err error
+}
+
+// NewActor starts an Actor under a Context spawned from parent, with a mailbox
+// of capacity size. The handler processes each message and returns the reply
+// to the caller of Ask, if any.
+//
+// A handler may spawn child actors from the Context it is handed.
+func NewActor[M, R any](parent Context, size int, handler func(ctx Context, msg M) (R, error)) *Actor[M, R] {
+	a := &Actor[M, R]{
+		ctx:     parent.Spawn(),
+		handler: handler,
+		mailbox: make(chan envelope[M, R], size),
+		done:    newsignalchan(),
+	}
+	go a.run()
+	return a
+}
+
+func (a *Actor[M, R]) run() {
+	defer close(a.done)
+	for {
+		select {
+		case env := <-a.mailbox:
+			if env.reply != nil && env.caller.Checkpoint() != nil {
+				continue // the caller gave up
+			}
+			v, err := a.handle(env.msg)
+			if env.reply != nil {
+				env.reply <- reply[R]{v, err}
+			}
+			if err != nil {
+				a.err = a.ctx.Finish(err)
+				return
+			}
+		case <-a.ctx.sigKill:
+			a.err = a.ctx.Finish(a.ctx.kill.cause())
+			return
+		}
+	}
+}
+
+// handle runs the handler, turning a panic into a PanicError.
+func (a *Actor[M, R]) handle(msg M) (v R, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	return a.handler(a.ctx, msg)
+}
+
+// Send posts msg to the mailbox of the actor, waiting for room if it is full,
+// unless the task controlled by c is cancelled first, in which case the
+// cancellation cause is returned. ErrActorStopped is returned if the actor
+// has stopped.
+func (a *Actor[M, R]) Send(c Controller, msg M) error {
+	return a.post(c, envelope[M, R]{msg: msg})
+}
+
+// Ask sends msg to the actor and waits for the reply, unless the task
+// controlled by c is cancelled first, in which case the cancellation cause is
+// returned. A message whose caller has given up by the time the actor gets to
+// it is skipped.
+func (a *Actor[M, R]) Ask(c Controller, msg M) (R, error) {
+	var zero R
+	env := envelope[M, R]{msg: msg, caller: c, reply: make(chan reply[R], 1)}
+	if err := a.post(c, env); err != nil {
+		return zero, err
+	}
+	select {
+	case r := <-env.reply:
+		return r.v, r.err
+	case <-c.sigKill:
+		return zero, c.kill.cause()
+	case <-a.done:
+		select {
+		case r := <-env.reply:
+			return r.v, r.err
+		default:
+			return zero, ErrActorStopped
+		}
+	}
+}
+
+func (a *Actor[M, R]) post(c Controller, env envelope[M, R]) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	select {
+	case <-a.done:
+		return ErrActorStopped
+	default:
+	}
+	select {
+	case a.mailbox <- env:
+		return nil
+	case <-c.sigKill:
+		return c.kill.cause()
+	case <-a.done:
+		return ErrActorStopped
+	}
+}
+
+// Stop cancels the actor and waits for it to stop. Messages left in the
+// mailbox are not processed.
+func (a *Actor[M, R]) Stop() {
+	a.ctx.Cancel()
+	<-a.done
+}
+
+// Done returns a channel that is closed once the actor has stopped.
+func (a *Actor[M, R]) Done() <-chan struct{} {
+	return a.done
+}
+
+// Wait blocks until the actor stops and returns the reason: the error of the
+// handler, or the cancellation cause of the actor's Controller.
+func (a *Actor[M, R]) Wait() error {
+	<-a.done
+	return a.err
+}
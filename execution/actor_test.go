@@ -0,0 +1,119 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mapStore is a Storer backed by a map.
+type mapStore map[interface{}]interface{}
+
+func (m mapStore) Get(key interface{}) (interface{}, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+func (m mapStore) Put(key, value interface{}) { m[key] = value }
+func (m mapStore) Delete(key interface{})     { delete(m, key) }
+func (m mapStore) Clear()                     { clear(m) }
+func (m mapStore) Clone() Storer {
+	n := make(mapStore, len(m))
+	for k, v := range m {
+		n[k] = v
+	}
+	return n
+}
+
+func counter(ctx Context, delta int) (int, error) {
+	n, _ := ctx.Get("n")
+	total, _ := n.(int)
+	total += delta
+	ctx.Put("n", total)
+	return total, nil
+}
+
+func TestActor(t *testing.T) {
+	root := NewContext(mapStore{})
+	a := NewActor(root, 4, counter)
+	c := NewController()
+	for i := 0; i < 3; i++ {
+		if err := a.Send(c, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := a.Ask(c, 10)
+	if n != 13 || err != nil {
+		t.Errorf("Expected 13 but got %v, %v", n, err)
+	}
+	if _, err := root.Get("n"); err == nil {
+		t.Error("The state of the actor should not leak into the parent Context.")
+	}
+
+	a.Stop()
+	if err := a.Wait(); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	if err := a.Send(c, 1); err != ErrActorStopped {
+		t.Errorf("Expected: %v but got: %v", ErrActorStopped, err)
+	}
+}
+
+func TestActorAskDeadline(t *testing.T) {
+	a := NewActor(NewContext(mapStore{}), 0, func(ctx Context, d time.Duration) (bool, error) {
+		return true, ctx.Sleep(d)
+	})
+	defer a.Stop()
+	c := NewController().CancelAfter(Timeout(10 * time.Millisecond))
+	if _, err := a.Ask(c, 100*time.Millisecond); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	if ok, err := a.Ask(NewController(), 0); !ok || err != nil {
+		t.Errorf("The actor should still be running. Got %v", err)
+	}
+}
+
+func TestActorFailure(t *testing.T) {
+	errFailed := errors.New("failed")
+	a := NewActor(NewContext(mapStore{}), 0, func(ctx Context, msg string) (string, error) {
+		if msg == "panic" {
+			ctx.Panic(msg)
+		}
+		return "", errFailed
+	})
+	if _, err := a.Ask(NewController(), "fail"); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+	if a.Wait() != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, a.Wait())
+	}
+
+	a = NewActor(NewContext(mapStore{}), 0, func(ctx Context, msg string) (string, error) {
+		ctx.Panic(msg)
+		return "", nil
+	})
+	var perr *PanicError
+	if _, err := a.Ask(NewController(), "boom"); !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("Expected a PanicError but got: %v", err)
+	}
+}
+
+func TestActorHierarchy(t *testing.T) {
+	type spawn struct{}
+	var child *Actor[int, int]
+	parent := NewActor(NewContext(mapStore{}), 0, func(ctx Context, _ spawn) (struct{}, error) {
+		child = NewActor(ctx, 0, counter)
+		return struct{}{}, nil
+	})
+	if _, err := parent.Ask(NewController(), spawn{}); err != nil {
+		t.Fatal(err)
+	}
+	parent.Stop()
+	select {
+	case <-child.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Stopping the parent should stop its child actors.")
+	}
+}
@@ -0,0 +1,195 @@
+package execution
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AdaptiveTimeout computes timeouts from the durations observed for previous
+// runs of a task, instead of relying on hard-coded durations.
+//
+// Durations are recorded per task name. The timeout of a task is the
+// configured percentile of its recent durations, plus a margin.
+// When a task times out, its duration is only known to be longer than what
+// was observed: it is recorded with a penalty so that the estimate grows.
+// Cancellations by a parent task tell nothing about durations and are ignored.
+//
+// An AdaptiveTimeout is safe for concurrent use. Its configuration fields must
+// not be modified once it is in use. The zero value uses the defaults of
+// NewAdaptiveTimeout for MinSamples, Window and Penalty, and a zero Initial.
+type AdaptiveTimeout struct {
+	// Percentile of the recorded durations, between 0 and 1.
+	Percentile float64
+	// Margin is added to the percentile.
+	Margin time.Duration
+	// Initial is the timeout used until MinSamples durations are recorded.
+	Initial time.Duration
+	// MinSamples is the number of durations needed for an estimate.
+	// Zero means 10.
+	MinSamples int
+	// Window is the number of most recent durations kept per task name.
+	// Zero means 100.
+	Window int
+	// Min and Max bound the computed timeouts. Zero means no bound.
+	Min, Max time.Duration
+	// Penalty is the factor applied to the duration of a task that timed out.
+	// Zero means 1.5.
+	Penalty float64
+
+	mu      sync.Mutex
+	samples map[string]*samples
+}
+
+// samples is a ring buffer of durations.
+type samples struct {
+	d    []time.Duration
+	next int
+}
+
+// NewAdaptiveTimeout returns an AdaptiveTimeout computing timeouts from the
+// given percentile of recorded durations plus margin, and using initial
+// until enough durations are recorded.
+// It keeps the last 100 durations per task name, needs 10 of them to
+// compute an estimate, and applies a penalty of 1.5 to timed out tasks.
+func NewAdaptiveTimeout(percentile float64, margin, initial time.Duration) *AdaptiveTimeout {
+	return &AdaptiveTimeout{
+		Percentile: percentile,
+		Margin:     margin,
+		Initial:    initial,
+		MinSamples: defaultMinSamples,
+		Window:     defaultWindow,
+		Penalty:    defaultPenalty,
+		samples:    make(map[string]*samples),
+	}
+}
+
+const (
+	defaultMinSamples = 10
+	defaultWindow     = 100
+	defaultPenalty    = 1.5
+)
+
+func (a *AdaptiveTimeout) minSamples() int {
+	if a.MinSamples <= 0 {
+		return defaultMinSamples
+	}
+	return a.MinSamples
+}
+
+func (a *AdaptiveTimeout) window() int {
+	if a.Window <= 0 {
+		return defaultWindow
+	}
+	return a.Window
+}
+
+func (a *AdaptiveTimeout) penalty() float64 {
+	if a.Penalty <= 0 {
+		return defaultPenalty
+	}
+	return a.Penalty
+}
+
+// Timeout returns the current timeout estimate for the task called name.
+func (a *AdaptiveTimeout) Timeout(name string) time.Duration {
+	a.mu.Lock()
+	s := a.samples[name]
+	var sorted []time.Duration
+	if s != nil && len(s.d) >= a.minSamples() {
+		sorted = append(sorted, s.d...)
+	}
+	a.mu.Unlock()
+
+	t := a.Initial
+	if sorted != nil {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		i := int(a.Percentile * float64(len(sorted)-1))
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(sorted) {
+			i = len(sorted) - 1
+		}
+		t = sorted[i] + a.Margin
+	}
+	if a.Min > 0 && t < a.Min {
+		t = a.Min
+	}
+	if a.Max > 0 && t > a.Max {
+		t = a.Max
+	}
+	return t
+}
+
+// Deadline returns a deadline for the task called name, about to be run as a
+// subtask of c. It is clamped by the deadline of c.
+func (a *AdaptiveTimeout) Deadline(c Controller, name string) time.Time {
+	t, _ := a.deadline(c, name)
+	return t
+}
+
+// deadline is similar to Deadline, and also reports whether the deadline was
+// clamped by the deadline of c.
+func (a *AdaptiveTimeout) deadline(c Controller, name string) (t time.Time, clamped bool) {
+	t = Timeout(a.Timeout(name))
+	if limit, ok := c.Deadline(); ok && limit.Before(t) {
+		return limit, true
+	}
+	return t, false
+}
+
+// Record records that the task called name ran for d and ended with err.
+func (a *AdaptiveTimeout) Record(name string, d time.Duration, err error) {
+	switch {
+	case errors.Is(err, ErrTimedOut):
+		d = time.Duration(float64(d) * a.penalty())
+	case errors.Is(err, ErrCancelled):
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.samples == nil {
+		a.samples = make(map[string]*samples)
+	}
+	s := a.samples[name]
+	if s == nil {
+		s = &samples{}
+		a.samples[name] = s
+	}
+	if len(s.d) < a.window() {
+		s.d = append(s.d, d)
+		return
+	}
+	s.d[s.next] = d
+	s.next = (s.next + 1) % len(s.d)
+}
+
+// Spawn returns a child of c whose deadline is computed for the task called
+// name, along with the function that must be called with the outcome of the
+// task once it has returned, so that its duration is recorded.
+//
+// When the child is cancelled, the cancellation cause takes precedence over
+// the error passed to done: a timeout is therefore told apart from a
+// cancellation by a parent. A timeout is only recorded if the adaptive
+// deadline of the child lapsed: no duration is recorded if the child ran out
+// of time because of the deadline of one of its ancestors.
+func (a *AdaptiveTimeout) Spawn(c Controller, name string) (child Controller, done func(err error)) {
+	start := time.Now()
+	deadline, clamped := a.deadline(c, name)
+	child = c.spawnUntil(deadline)
+	return child, func(err error) {
+		if cause := child.Checkpoint(); cause != nil {
+			child.kill.mu.Lock()
+			lapsed := child.kill.lapsed
+			child.kill.mu.Unlock()
+			if errors.Is(cause, ErrTimedOut) && (clamped || !lapsed) {
+				return
+			}
+			err = cause
+		}
+		a.Record(name, time.Since(start), err)
+	}
+}
@@ -0,0 +1,90 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	a := NewAdaptiveTimeout(0.9, 5*time.Millisecond, time.Second)
+	if d := a.Timeout("query"); d != time.Second {
+		t.Errorf("Expected the initial timeout but got %v", d)
+	}
+
+	for i := 1; i <= 10; i++ {
+		a.Record("query", time.Duration(i)*time.Millisecond, nil)
+	}
+	if d := a.Timeout("query"); d != 14*time.Millisecond {
+		t.Errorf("Expected 9ms + 5ms but got %v", d)
+	}
+
+	a.Record("query", time.Hour, ErrCancelled)
+	if d := a.Timeout("query"); d != 14*time.Millisecond {
+		t.Errorf("Cancellations should be ignored. Got %v", d)
+	}
+
+	for i := 0; i < 10; i++ {
+		a.Record("query", 20*time.Millisecond, ErrTimedOut)
+	}
+	if d := a.Timeout("query"); d != 35*time.Millisecond {
+		t.Errorf("Timeouts should be penalized. Expected 30ms + 5ms but got %v", d)
+	}
+
+	a.Max = 20 * time.Millisecond
+	if d := a.Timeout("query"); d != 20*time.Millisecond {
+		t.Errorf("Expected the timeout to be capped at 20ms but got %v", d)
+	}
+}
+
+func TestAdaptiveTimeoutSpawn(t *testing.T) {
+	a := NewAdaptiveTimeout(0.5, 0, time.Hour)
+	limit := Timeout(time.Minute)
+	c := NewController().CancelAfter(limit)
+
+	child, done := a.Spawn(c, "task")
+	if d, _ := child.Deadline(); !d.Equal(limit) {
+		t.Errorf("The deadline should be clamped by the parent's: expected %v, got %v", limit, d)
+	}
+	done(nil)
+
+	a.Initial = 2 * time.Millisecond
+	a.MinSamples = 100
+	child, done = a.Spawn(NewController(), "other")
+	<-child.WasCancelled(nil)
+	done(nil)
+	a.mu.Lock()
+	recorded := a.samples["other"].d[0]
+	a.mu.Unlock()
+	if recorded < 3*time.Millisecond {
+		t.Errorf("A timed out task should be recorded with a penalty. Got %v", recorded)
+	}
+}
+
+func TestAdaptiveTimeoutZeroValue(t *testing.T) {
+	var a AdaptiveTimeout
+	for i := 0; i < 10; i++ {
+		a.Record("query", 10*time.Millisecond, nil)
+	}
+	if d := a.Timeout("query"); d != 10*time.Millisecond {
+		t.Errorf("Expected 10ms but got %v", d)
+	}
+}
+
+func TestAdaptiveTimeoutAncestorDeadline(t *testing.T) {
+	a := NewAdaptiveTimeout(0.5, 0, time.Hour)
+	parent := NewController()
+	child, done := a.Spawn(parent.Spawn(), "task")
+	parent.Renew(2 * time.Millisecond)
+	<-child.WasCancelled(nil)
+	done(nil)
+
+	clamped, done := a.Spawn(NewController().CancelAfter(Timeout(2*time.Millisecond)), "task")
+	<-clamped.WasCancelled(nil)
+	done(nil)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if s := a.samples["task"]; s != nil {
+		t.Errorf("The deadlines of the ancestors should not be recorded. Got %v", s.d)
+	}
+}
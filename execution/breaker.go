@@ -0,0 +1,166 @@
+package execution
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a Breaker that fails fast.
+var ErrCircuitOpen = errors.New("Circuit is open!")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// Closed lets every call through.
+	Closed BreakerState = iota
+	// Open fails fast.
+	Open
+	// HalfOpen lets a single probe through to decide whether to close again.
+	HalfOpen
+)
+
+// A Breaker is a circuit breaker protecting callers from a failing downstream
+// dependency: after too many consecutive failures, it opens and fails fast
+// with ErrCircuitOpen instead of letting calls drag the deadlines of their
+// callers to the limit.
+// After a cooldown, a single call is let through as a probe, under its own
+// short deadline. If it succeeds, the Breaker closes again.
+//
+// A call that fails with ErrTimedOut, or with any other error, counts as a
+// failure. A call that fails with ErrCancelled does not: the caller gave up,
+// which says nothing about the dependency.
+type Breaker struct {
+	// Threshold is the number of consecutive failures that opens the Breaker.
+	Threshold int
+	// Cooldown is the time during which an open Breaker fails fast.
+	Cooldown time.Duration
+	// ProbeTimeout limits the duration of a probe. Zero means no limit other
+	// than the deadline of the caller.
+	ProbeTimeout time.Duration
+	// Observer, if not nil, is notified of the state changes of the Breaker,
+	// via BreakerOpened, BreakerHalfOpened and BreakerClosed events.
+	Observer Observer
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed Breaker.
+func NewBreaker(threshold int, cooldown, probeTimeout time.Duration) *Breaker {
+	return &Breaker{
+		Threshold:    threshold,
+		Cooldown:     cooldown,
+		ProbeTimeout: probeTimeout,
+	}
+}
+
+// State returns the current state of the Breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && time.Since(b.openedAt) >= b.Cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Do runs op under a child of c, unless the Breaker fails fast, in which case
+// ErrCircuitOpen is returned.
+// The outcome of op is accounted for and returned.
+func (b *Breaker) Do(c Controller, op func(Controller) error) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	probe, err := b.admit()
+	if err != nil {
+		return err
+	}
+
+	if probe {
+		// Released even if op panics, so that another probe may go through.
+		defer b.release()
+	}
+	var child Controller
+	if probe && b.ProbeTimeout > 0 {
+		child = c.spawnUntil(Timeout(b.ProbeTimeout))
+	} else {
+		child = c.Spawn()
+	}
+	err = child.Finish(op(child))
+	b.account(probe, err)
+	return err
+}
+
+// admit decides whether a call may go through and whether it is a probe.
+func (b *Breaker) admit() (probe bool, err error) {
+	b.mu.Lock()
+	switch b.state {
+	case Closed:
+		b.mu.Unlock()
+		return false, nil
+	case Open:
+		if time.Since(b.openedAt) < b.Cooldown {
+			b.mu.Unlock()
+			return false, ErrCircuitOpen
+		}
+		b.state = HalfOpen
+	}
+	if b.probing {
+		b.mu.Unlock()
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	b.mu.Unlock()
+	b.emit(BreakerHalfOpened)
+	return true, nil
+}
+
+// release marks the end of a probe.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// account updates the state of the Breaker from the outcome of a call.
+// Only the outcome of a probe may move the Breaker out of the Open and
+// HalfOpen states: a call admitted while it was Closed, that completes after it
+// has opened, says nothing about whether the dependency has recovered.
+func (b *Breaker) account(probe bool, err error) {
+	b.mu.Lock()
+	var change EventKind = -1
+	switch {
+	case errors.Is(err, ErrCancelled):
+		// Neutral: a cancelled probe leaves room for another one.
+	case !probe && b.state != Closed:
+		// Stale outcome of a call admitted before the Breaker opened.
+	case err == nil:
+		b.failures = 0
+		if b.state != Closed {
+			b.state = Closed
+			change = BreakerClosed
+		}
+	default:
+		b.failures++
+		if probe || b.failures >= b.Threshold {
+			b.state = Open
+			b.openedAt = time.Now()
+			change = BreakerOpened
+		}
+	}
+	b.mu.Unlock()
+	if change >= 0 {
+		b.emit(change)
+	}
+}
+
+func (b *Breaker) emit(kind EventKind) {
+	if b.Observer != nil {
+		b.Observer(Event{Kind: kind, At: time.Now()})
+	}
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var events []EventKind
+	b := NewBreaker(2, 10*time.Millisecond, 5*time.Millisecond)
+	b.Observer = func(e Event) { events = append(events, e.Kind) }
+	c := NewController()
+
+	slow := func(c Controller) error { return c.Sleep(time.Second) }
+	ok := func(c Controller) error { return nil }
+
+	// A caller giving up does not trip the breaker.
+	for i := 0; i < 3; i++ {
+		caller := c.Spawn()
+		b.Do(caller, func(c Controller) error {
+			caller.Cancel()
+			return c.Checkpoint()
+		})
+	}
+	if b.State() != Closed {
+		t.Fatal("Cancellations should not open the breaker.")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Do(c.spawnUntil(Timeout(time.Millisecond)), slow); err != ErrTimedOut {
+			t.Fatalf("Expected: %v but got: %v", ErrTimedOut, err)
+		}
+	}
+	if b.State() != Open {
+		t.Fatal("Timeouts should have opened the breaker.")
+	}
+	if err := b.Do(c, ok); err != ErrCircuitOpen {
+		t.Errorf("Expected: %v but got: %v", ErrCircuitOpen, err)
+	}
+
+	// After the cooldown, the probe runs under its own short deadline.
+	time.Sleep(10 * time.Millisecond)
+	if err := b.Do(c, slow); err != ErrTimedOut {
+		t.Errorf("The probe should have timed out. Got %v", err)
+	}
+	if b.State() != Open {
+		t.Fatal("A failed probe should reopen the breaker.")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := b.Do(c, ok); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if b.State() != Closed {
+		t.Error("A successful probe should close the breaker.")
+	}
+
+	want := []EventKind{BreakerOpened, BreakerHalfOpened, BreakerOpened, BreakerHalfOpened, BreakerClosed}
+	if len(events) != len(want) {
+		t.Fatalf("Expected events %v but got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("Expected events %v but got %v", want, events)
+		}
+	}
+}
+
+func TestBreakerErrors(t *testing.T) {
+	b := NewBreaker(1, time.Hour, 0)
+	errFailed := errors.New("failed")
+	if err := b.Do(NewController(), func(c Controller) error { return errFailed }); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+	if b.State() != Open {
+		t.Error("Errors should open the breaker.")
+	}
+}
+
+func TestBreakerStaleOutcome(t *testing.T) {
+	b := NewBreaker(1, time.Hour, 0)
+	c := NewController()
+	errFailed := errors.New("failed")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Do(c, func(c Controller) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	b.Do(c, func(c Controller) error { return errFailed })
+	if b.State() != Open {
+		t.Fatal("The failure should have opened the breaker.")
+	}
+	close(release)
+	<-done
+	if b.State() != Open {
+		t.Error("Only a probe should be able to close an open breaker.")
+	}
+}
+
+func TestBreakerPanickingProbe(t *testing.T) {
+	b := NewBreaker(1, 10*time.Millisecond, 0)
+	c := NewController()
+	b.Do(c, func(c Controller) error { return errors.New("failed") })
+	time.Sleep(20 * time.Millisecond)
+
+	func() {
+		defer func() { recover() }()
+		b.Do(c, func(c Controller) error { panic("boom") })
+	}()
+	if err := b.Do(c, func(c Controller) error { return nil }); err != nil || b.State() != Closed {
+		t.Errorf("A new probe should be let through after a panicking one. Got %v", err)
+	}
+}
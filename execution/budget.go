@@ -0,0 +1,99 @@
+package execution
+
+import (
+	"time"
+)
+
+// A Budget splits the remaining time of a task between the sequential stages
+// it goes through, e.g. a database call followed by the writing of a response.
+//
+// Each stage is run under a child Controller whose deadline is computed from
+// the time remaining when the stage starts.
+// A Budget is not safe for concurrent use.
+type Budget struct {
+	c       Controller
+	reserve time.Duration
+	minimum time.Duration
+}
+
+// Budget returns a Budget for the stages of the task controlled by c.
+func (c Controller) Budget() *Budget {
+	return &Budget{c: c}
+}
+
+// Reserve sets aside d for the stages that come last: the time allotted to a
+// stage by Fraction is computed from the remaining time minus d.
+// The stages that come last draw on the reserve via Fixed or Rest.
+func (b *Budget) Reserve(d time.Duration) *Budget {
+	b.reserve = d
+	return b
+}
+
+// Minimum sets the minimum time that a stage must be allotted to be started.
+// A stage that would be allotted less fails fast with ErrTimedOut.
+func (b *Budget) Minimum(d time.Duration) *Budget {
+	b.minimum = d
+	return b
+}
+
+// Remaining returns the time that can be allotted to the next stage, i.e. the
+// time left before the deadline of the task, minus the reserve.
+// ok is false if the task has no deadline.
+func (b *Budget) Remaining() (d time.Duration, ok bool) {
+	left, ok := b.left()
+	if !ok {
+		return 0, false
+	}
+	return max(left-b.reserve, 0), true
+}
+
+// Fraction returns a child Controller for a stage that is allotted the
+// fraction f (between 0 and 1) of the remaining time.
+// If the task has no deadline, neither does the stage.
+func (b *Budget) Fraction(f float64) (Controller, error) {
+	remaining, ok := b.Remaining()
+	if !ok {
+		return b.stage(0, false)
+	}
+	return b.stage(time.Duration(float64(remaining)*f), true)
+}
+
+// Fixed returns a child Controller for a stage that is allotted d, or the
+// time left before the deadline of the task if it is shorter, the reserve
+// included.
+func (b *Budget) Fixed(d time.Duration) (Controller, error) {
+	if left, ok := b.left(); ok && left < d {
+		d = left
+	}
+	return b.stage(d, true)
+}
+
+// Rest returns a child Controller for the last stage, which is allotted all
+// the time left before the deadline of the task, the reserve included.
+// If the task has no deadline, neither does the stage.
+func (b *Budget) Rest() (Controller, error) {
+	left, ok := b.left()
+	return b.stage(left, ok)
+}
+
+// left returns the time left before the deadline of the task.
+func (b *Budget) left() (time.Duration, bool) {
+	deadline, ok := b.c.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(deadline), 0), true
+}
+
+func (b *Budget) stage(d time.Duration, limited bool) (Controller, error) {
+	if err := b.c.Checkpoint(); err != nil {
+		return Controller{}, err
+	}
+	if !limited {
+		return b.c.Spawn(), nil
+	}
+	if d <= 0 || d < b.minimum {
+		return Controller{}, ErrTimedOut
+	}
+	return b.c.spawnUntil(Timeout(d)), nil
+}
@@ -0,0 +1,71 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(100 * time.Millisecond))
+	b := c.Budget().Reserve(50 * time.Millisecond)
+
+	db, err := b.Fraction(0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _ := db.Deadline()
+	if left := time.Until(d); left > 31*time.Millisecond || left < 20*time.Millisecond {
+		t.Errorf("The stage should have been allotted about 30ms but got %v", left)
+	}
+
+	last, err := b.Rest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _ = last.Deadline()
+	if left := time.Until(d); left > 101*time.Millisecond || left < 80*time.Millisecond {
+		t.Errorf("The last stage should have been allotted about 100ms but got %v", left)
+	}
+
+	c.Cancel()
+	if _, err := b.Fixed(time.Millisecond); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestBudgetMinimum(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(20 * time.Millisecond))
+	b := c.Budget().Reserve(15 * time.Millisecond).Minimum(10 * time.Millisecond)
+	if _, err := b.Fraction(1); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+
+	stage, err := NewController().Budget().Minimum(time.Second).Fraction(0.5)
+	if err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if _, ok := stage.Deadline(); ok {
+		t.Error("A stage of a task without deadline should have no deadline.")
+	}
+}
+
+func TestBudgetReserve(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(100 * time.Millisecond))
+	b := c.Budget().Reserve(50 * time.Millisecond)
+
+	first, err := b.Fraction(0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Sleep(30 * time.Millisecond)
+	first.Finish(nil)
+
+	fixed, err := b.Fixed(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _ := fixed.Deadline()
+	if left := time.Until(d); left < 40*time.Millisecond {
+		t.Errorf("The stage should draw on the reserve to be allotted 50ms but got %v", left)
+	}
+}
@@ -0,0 +1,135 @@
+package execution
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// A Cache is a loading cache: values missing from the cache are loaded on
+// demand by a loader function, which runs under a Controller.
+//
+// Concurrent loads of the same key are coalesced, as with SingleFlight. Each
+// caller waits for the value within its own deadline.
+// Entries expire after a time-to-live, and the least recently used entries
+// are evicted when the cache is full.
+//
+// A Cache is scoped to a root Controller: loads are spawned from it and, once
+// it is cancelled, all the entries and the loads in flight are dropped, and
+// Get returns the cancellation cause of the root.
+type Cache[K comparable, V any] struct {
+	root   Controller
+	size   int
+	ttl    time.Duration
+	loader func(Controller, K) (V, error)
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	lru     list.List
+	flights SingleFlight[K, V]
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// NewCache returns a Cache scoped to root, holding at most size entries, each
+// for at most ttl, and loading values with loader.
+// A size or ttl of zero means no limit.
+func NewCache[K comparable, V any](root Controller, size int, ttl time.Duration, loader func(Controller, K) (V, error)) *Cache[K, V] {
+	c := &Cache[K, V]{
+		root:    root,
+		size:    size,
+		ttl:     ttl,
+		loader:  loader,
+		entries: make(map[K]*list.Element),
+	}
+	c.flights.scope = &c.root
+	root.AfterCancel(func() {
+		c.mu.Lock()
+		c.entries = make(map[K]*list.Element)
+		c.lru.Init()
+		c.mu.Unlock()
+	})
+	return c
+}
+
+// Get returns the value cached for key, loading it if needed on behalf of the
+// task controlled by ctrl.
+// Errors returned by the loader are not cached.
+func (c *Cache[K, V]) Get(ctrl Controller, key K) (V, error) {
+	if err := c.root.Checkpoint(); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*cacheEntry[K, V])
+		if e.expires.IsZero() || time.Now().Before(e.expires) {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return e.value, nil
+		}
+		c.remove(elem)
+	}
+	c.mu.Unlock()
+
+	v, err, _ := c.flights.Do(ctrl, key, func(l Controller) (V, error) {
+		v, err := c.loader(l, key)
+		if err == nil {
+			c.add(key, v)
+		}
+		return v, err
+	})
+	if cause := c.root.Checkpoint(); cause != nil {
+		var zero V
+		return zero, cause
+	}
+	return v, err
+}
+
+// add inserts a loaded value, evicting the least recently used entry if the
+// cache is full. Nothing is inserted once the root has been cancelled.
+func (c *Cache[K, V]) add(key K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.root.Checkpoint() != nil {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	e := &cacheEntry[K, V]{key: key, value: v}
+	if c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	if c.size > 0 && c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes an entry. c.mu must be held.
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry[K, V]).key)
+}
+
+// Invalidate removes the entry cached for key, if any.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,107 @@
+package execution
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var loads atomic.Int32
+	cache := NewCache(NewController(), 2, 0, func(c Controller, k int) (string, error) {
+		loads.Add(1)
+		return strconv.Itoa(k), nil
+	})
+	c := NewController()
+
+	for i := 0; i < 3; i++ {
+		if v, err := cache.Get(c, 1); v != "1" || err != nil {
+			t.Fatalf("Unexpected result: %v, %v", v, err)
+		}
+	}
+	if loads.Load() != 1 {
+		t.Errorf("Expected a single load but got %v", loads.Load())
+	}
+
+	cache.Get(c, 2)
+	cache.Get(c, 1)
+	cache.Get(c, 3) // evicts 2, the least recently used
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries but got %v", cache.Len())
+	}
+	loads.Store(0)
+	cache.Get(c, 1)
+	cache.Get(c, 2)
+	if loads.Load() != 1 {
+		t.Errorf("Only the evicted entry should have been reloaded. Got %v loads", loads.Load())
+	}
+}
+
+func TestCacheTTLAndErrors(t *testing.T) {
+	var loads atomic.Int32
+	errFailed := errors.New("failed")
+	cache := NewCache(NewController(), 0, 5*time.Millisecond, func(c Controller, k string) (int, error) {
+		if loads.Add(1) == 1 {
+			return 0, errFailed
+		}
+		return 1, nil
+	})
+	c := NewController()
+
+	if _, err := cache.Get(c, "k"); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+	cache.Get(c, "k")
+	cache.Get(c, "k")
+	if loads.Load() != 2 {
+		t.Errorf("Errors should not be cached. Got %v loads", loads.Load())
+	}
+	time.Sleep(10 * time.Millisecond)
+	cache.Get(c, "k")
+	if loads.Load() != 3 {
+		t.Errorf("Expired entries should be reloaded. Got %v loads", loads.Load())
+	}
+}
+
+func TestCacheScope(t *testing.T) {
+	root := NewController()
+	loading := make(chan Controller, 1)
+	cache := NewCache(root, 0, 0, func(c Controller, k int) (int, error) {
+		if k == 0 {
+			return 0, nil
+		}
+		loading <- c
+		return 0, c.Sleep(time.Second)
+	})
+	c := NewController()
+	cache.Get(c, 0)
+
+	// The caller's deadline is respected.
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if _, err := cache.Get(d, 1); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	<-loading
+
+	errs := make(chan error)
+	go func() {
+		_, err := cache.Get(c, 2)
+		errs <- err
+	}()
+	load := <-loading
+	root.Cancel()
+	if err := <-errs; err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	if err := load.Checkpoint(); err != ErrCancelled {
+		t.Errorf("The load in flight should have been cancelled. Got %v", err)
+	}
+	for i := 0; i < 100 && cache.Len() != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("The entries should have been dropped. Got %v", cache.Len())
+	}
+}
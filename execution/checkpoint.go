@@ -0,0 +1,112 @@
+package execution
+
+import (
+	"reflect"
+	"time"
+)
+
+// Checkpoint reports whether the task controlled by c may keep on running.
+// It returns nil if that is the case. Otherwise, it returns the precise
+// reason for which the task was cancelled, i.e. ErrCancelled or ErrTimedOut.
+//
+// It is meant to be called at regular intervals within long-running loops,
+// in place of a select statement on WasCancelled:
+//
+//	for i := 0; i < n; i++ {
+//		if err := c.Checkpoint(); err != nil {
+//			return err
+//		}
+//		// processing...
+//	}
+//
+// Checkpoint does not allocate.
+func (c Controller) Checkpoint() error {
+	select {
+	case <-c.sigKill:
+		return c.kill.cause()
+	default:
+	}
+	c.kill.expired()
+	select {
+	case <-c.sigKill:
+		return c.kill.cause()
+	default:
+		return nil
+	}
+}
+
+// Sleep pauses the current goroutine for at least the duration d unless the
+// task gets cancelled in the meantime.
+// It returns nil if the task slept for the whole duration and the
+// cancellation cause otherwise.
+func (c Controller) Sleep(d time.Duration) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-c.sigKill:
+		return c.kill.cause()
+	}
+}
+
+// Send sends v on the channel ch unless the task controlled by c is cancelled
+// first, in which case the cancellation cause is returned.
+func Send[T any](c Controller, ch chan<- T, v T) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	select {
+	case ch <- v:
+		return nil
+	case <-c.sigKill:
+		return c.kill.cause()
+	}
+}
+
+// Recv receives a value from the channel ch unless the task controlled by c is
+// cancelled first, in which case the cancellation cause is returned.
+// As for a regular receive operation, ok is false if ch has been closed.
+func Recv[T any](c Controller, ch <-chan T) (v T, ok bool, err error) {
+	if err = c.Checkpoint(); err != nil {
+		return v, false, err
+	}
+	select {
+	case v, ok = <-ch:
+		return v, ok, nil
+	case <-c.sigKill:
+		return v, false, c.kill.cause()
+	}
+}
+
+// Select blocks until one of the cases can proceed or until the task
+// controlled by c is cancelled.
+// The set of cases is dynamic: it is built at runtime, as for reflect.Select
+// whose semantics are otherwise preserved.
+//
+// If the task is cancelled, chosen is -1 and err holds the cancellation cause.
+// Default cases are not allowed since Select is meant to block.
+func (c Controller) Select(cases ...reflect.SelectCase) (chosen int, recv reflect.Value, recvOK bool, err error) {
+	if err = c.Checkpoint(); err != nil {
+		return -1, recv, false, err
+	}
+	all := make([]reflect.SelectCase, len(cases)+1)
+	all[0] = reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(c.sigKill),
+	}
+	for i, sc := range cases {
+		if sc.Dir == reflect.SelectDefault {
+			panic("Select does not accept default cases.")
+		}
+		all[i+1] = sc
+	}
+	chosen, recv, recvOK = reflect.Select(all)
+	if chosen == 0 {
+		return -1, reflect.Value{}, false, c.kill.cause()
+	}
+	return chosen - 1, recv, recvOK, nil
+}
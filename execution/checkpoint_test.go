@@ -0,0 +1,99 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	c := NewController()
+	if err := c.Checkpoint(); err != nil {
+		t.Errorf("Expected no error from a live controller but got %v", err)
+	}
+
+	child := c.Spawn()
+	grandchild := child.Spawn()
+	c.Cancel()
+	if err := grandchild.Checkpoint(); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond)).Spawn()
+	time.Sleep(3 * time.Millisecond)
+	if err := d.Checkpoint(); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+
+	live := NewController()
+	allocs := testing.AllocsPerRun(100, func() {
+		live.Checkpoint()
+	})
+	if allocs != 0 {
+		t.Errorf("Checkpoint should not allocate but did %v times", allocs)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	c := NewController()
+	if err := c.Sleep(time.Millisecond); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	d := NewController().CancelAfter(Timeout(5 * time.Millisecond))
+	start := time.Now()
+	if err := d.Sleep(time.Second); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Sleep should have been interrupted by the deadline.")
+	}
+}
+
+func TestSendRecv(t *testing.T) {
+	c := NewController()
+	ch := make(chan int, 1)
+
+	if err := Send(c, ch, 42); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	v, ok, err := Recv(c, ch)
+	if v != 42 || !ok || err != nil {
+		t.Errorf("Expected (42, true, nil) but got (%v, %v, %v)", v, ok, err)
+	}
+
+	child := c.Spawn()
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		c.Cancel()
+	}()
+	if _, _, err := Recv(child, ch); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	ch <- 1
+	if err := Send(child, ch, 2); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	c := NewController()
+	a := make(chan int)
+	b := make(chan string, 1)
+	b <- "hello"
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(a)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(b)},
+	}
+	chosen, recv, ok, err := c.Select(cases...)
+	if chosen != 1 || recv.String() != "hello" || !ok || err != nil {
+		t.Errorf("Unexpected select outcome: %v %v %v %v", chosen, recv, ok, err)
+	}
+
+	d := c.CancelAfter(Timeout(2 * time.Millisecond))
+	chosen, _, _, err = d.Select(cases...)
+	if chosen != -1 || err != ErrTimedOut {
+		t.Errorf("Expected (-1, %v) but got (%v, %v)", ErrTimedOut, chosen, err)
+	}
+}
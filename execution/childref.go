@@ -0,0 +1,27 @@
+//go:build go1.24
+
+package execution
+
+import (
+	"runtime"
+	"weak"
+)
+
+// childref is the reference a killswitch holds to each of its children.
+// It is weak, so that a subtask dropped without being cancelled or finished
+// can be garbage collected, at which point its parent forgets about it.
+type childref = weak.Pointer[killswitch]
+
+func refer(ks *killswitch) childref {
+	return weak.Make(ks)
+}
+
+func deref(r childref) *killswitch {
+	return r.Value()
+}
+
+// collectable arranges for parent to forget about its child ks once ks has
+// been garbage collected.
+func collectable(parent, ks *killswitch) {
+	runtime.AddCleanup(ks, parent.forget, ks.self)
+}
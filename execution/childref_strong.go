@@ -0,0 +1,18 @@
+//go:build !go1.24
+
+package execution
+
+// childref is the reference a killswitch holds to each of its children.
+// Weak pointers are only available from Go 1.24 onwards: before that, a
+// subtask stays attached to its parent until it is cancelled or finished.
+type childref = *killswitch
+
+func refer(ks *killswitch) childref {
+	return ks
+}
+
+func deref(r childref) *killswitch {
+	return r
+}
+
+func collectable(parent, ks *killswitch) {}
@@ -0,0 +1,139 @@
+package execution
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCleanupTimedOut is returned when the cleanup functions of a task did not
+// complete in the time that was allotted to them.
+var ErrCleanupTimedOut = errors.New("Cleanup ran out of time!")
+
+// AfterCancel arranges for f to be called in its own goroutine once c has been
+// cancelled, whether manually, by a parent task, or because time ran out.
+// If c has already been cancelled, f is called right away.
+// Until then, the task stays attached to its parent so that f is not lost.
+//
+// The returned stop function unregisters f. It returns true if it prevented f
+// from being run, and false if f has already been started or stop was already
+// called.
+func (c Controller) AfterCancel(f func()) (stop func() bool) {
+	ks := c.kill
+	ks.pin()
+	ks.mu.Lock()
+	if ks.err != nil {
+		ks.mu.Unlock()
+		go f()
+		return func() bool { return false }
+	}
+	key := &f
+	if ks.afterfuncs == nil {
+		ks.afterfuncs = make(map[*func()]struct{})
+	}
+	ks.afterfuncs[key] = struct{}{}
+	ks.mu.Unlock()
+
+	return func() bool {
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		if _, ok := ks.afterfuncs[key]; !ok {
+			return false
+		}
+		delete(ks.afterfuncs, key)
+		return true
+	}
+}
+
+// Defer pushes f onto the cleanup stack of c.
+//
+// The functions of the cleanup stack are run exactly once, in reverse order of
+// registration, when c is cancelled, times out, or when its task finishes
+// (see Finish). They are typically used to release the resources that were
+// acquired by a task: files, temporary directories, network connections...
+// The errors they return are collected and reported by Finish.
+//
+// If c has already been cancelled, f is run once the functions that are still
+// on the cleanup stack have completed, or right away if there are none: it may
+// notably be called from a cleanup function. Otherwise, the task stays
+// attached to its parent until it is cancelled or finished.
+func (c Controller) Defer(f func() error) {
+	ks := c.kill
+	ks.pin()
+	ks.mu.Lock()
+	if ks.err == nil || ks.cleaning {
+		ks.cleanup = append(ks.cleanup, f)
+		ks.mu.Unlock()
+		return
+	}
+	ks.mu.Unlock()
+	if err := f(); err != nil {
+		ks.mu.Lock()
+		ks.cleanupErr = append(ks.cleanupErr, err)
+		ks.mu.Unlock()
+	}
+}
+
+// clean runs the cleanup stack of a killswitch once it has been triggered,
+// along with the functions that are pushed onto it in the meantime.
+func (ks *killswitch) clean(stack []func() error) {
+	for len(stack) > 0 {
+		var errs []error
+		for i := len(stack) - 1; i >= 0; i-- {
+			if err := stack[i](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		ks.mu.Lock()
+		ks.cleanupErr = append(ks.cleanupErr, errs...)
+		stack = ks.cleanup
+		ks.cleanup = nil
+		if len(stack) == 0 {
+			ks.cleaning = false
+			close(ks.cleaned)
+		}
+		ks.mu.Unlock()
+	}
+}
+
+// Finish marks the end of the task controlled by c.
+// The subtasks that may still be running are cancelled and the cleanup stack
+// is run if it has not been already. The task is detached from its parent.
+//
+// It returns the final error of the task: err joined with any error returned
+// by the cleanup functions. A single error is returned as is.
+func (c Controller) Finish(err error) error {
+	return c.finish(err, nil)
+}
+
+// FinishWithin is similar to Finish but waits at most d for the cleanup
+// functions to complete.
+// If they do not complete in time, ErrCleanupTimedOut is joined to the final
+// error. The cleanup functions are not interrupted.
+func (c Controller) FinishWithin(d time.Duration, err error) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	return c.finish(err, t.C)
+}
+
+func (c Controller) finish(err error, expired <-chan time.Time) error {
+	c.Cancel()
+
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	select {
+	case <-c.kill.cleaned:
+	case <-expired:
+		errs = append(errs, ErrCleanupTimedOut)
+	}
+
+	c.kill.mu.Lock()
+	errs = append(errs, c.kill.cleanupErr...)
+	c.kill.mu.Unlock()
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAfterCancel(t *testing.T) {
+	c := NewController()
+	child := c.Spawn()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	child.AfterCancel(func() { wg.Done() })
+
+	stop := child.AfterCancel(func() { t.Error("This function should have been unregistered.") })
+	if !stop() {
+		t.Error("stop should have prevented the function from running.")
+	}
+	if stop() {
+		t.Error("A second call to stop should report false.")
+	}
+
+	c.Cancel()
+	wg.Wait()
+
+	wg.Add(1)
+	if child.AfterCancel(func() { wg.Done() })() {
+		t.Error("stop should report false once the controller is cancelled.")
+	}
+	wg.Wait()
+}
+
+func TestCleanupStack(t *testing.T) {
+	c := NewController()
+	var order []int
+	errFirst := errors.New("first")
+	c.Defer(func() error { order = append(order, 1); return errFirst })
+	c.Defer(func() error { order = append(order, 2); return nil })
+	c.Defer(func() error { order = append(order, 3); return nil })
+
+	errTask := errors.New("task")
+	err := c.Finish(errTask)
+	if !errors.Is(err, errTask) || !errors.Is(err, errFirst) {
+		t.Errorf("The final error should wrap both the task and cleanup errors. Got %v", err)
+	}
+	if len(order) != 3 || order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Errorf("Cleanup functions should run in reverse order. Got %v", order)
+	}
+
+	// Running Finish again should not run the cleanup functions twice.
+	c.Finish(nil)
+	if len(order) != 3 {
+		t.Errorf("Cleanup functions should run exactly once. Got %v", order)
+	}
+
+	if err := NewController().Finish(nil); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if err := NewController().Finish(errTask); err != errTask {
+		t.Errorf("A single error should be returned as is. Got %v", err)
+	}
+}
+
+func TestCleanupOnTimeout(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	done := make(chan struct{})
+	c.Defer(func() error { close(done); return nil })
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("The cleanup stack should have been run when time ran out.")
+	}
+}
+
+func TestFinishWithin(t *testing.T) {
+	c := NewController()
+	release := make(chan struct{})
+	c.Defer(func() error { <-release; return nil })
+
+	err := c.FinishWithin(2*time.Millisecond, nil)
+	if !errors.Is(err, ErrCleanupTimedOut) {
+		t.Errorf("Expected: %v but got: %v", ErrCleanupTimedOut, err)
+	}
+	close(release)
+}
+
+func TestDeferWhileCleaning(t *testing.T) {
+	c := NewController()
+	var order []int
+	errLate := errors.New("late")
+	c.Defer(func() error {
+		order = append(order, 1)
+		c.Defer(func() error { order = append(order, 2); return errLate })
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- c.Finish(nil) }()
+	select {
+	case err := <-done:
+		if err != errLate {
+			t.Errorf("Expected: %v but got: %v", errLate, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Deferring a function from a cleanup function should not block.")
+	}
+	if len(order) != 2 || order[1] != 2 {
+		t.Errorf("A function deferred while cleaning should run after the stack. Got %v", order)
+	}
+
+	ran := false
+	c.Defer(func() error { ran = true; return nil })
+	if !ran {
+		t.Error("A function deferred once cleaned should run right away.")
+	}
+}
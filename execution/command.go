@@ -0,0 +1,193 @@
+package execution
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// DefaultGracePeriod is the time left to a cancelled subprocess to exit after
+// it has been asked to terminate, before it is killed.
+const DefaultGracePeriod = 5 * time.Second
+
+// Cmd is an exec.Cmd whose lifetime is tied to a Controller.
+//
+// The subprocess is started in its own process group. When the Controller is
+// cancelled, the whole group is sent SIGTERM, then SIGKILL if it has not exited
+// after GracePeriod. Once the subprocess has exited, whatever is left running
+// in its process group (grandchildren...) is killed.
+//
+// On platforms without process groups, the subprocess alone is killed.
+type Cmd struct {
+	*exec.Cmd
+
+	// GracePeriod is the time between the termination request and the kill
+	// signal. If zero, DefaultGracePeriod is used.
+	GracePeriod time.Duration
+
+	// Propagate specifies whether the subprocess should be given the means to
+	// mirror the Controller via FromEnvironment. See Export.
+	Propagate bool
+
+	c         Controller
+	done      chan struct{}
+	watched   chan struct{}
+	signalled bool
+	waited    bool
+	release   func()
+}
+
+// Command returns a Cmd that executes the named program with the given
+// arguments on behalf of the task controlled by c.
+// The returned Cmd can be configured as an exec.Cmd would be before being
+// started.
+func Command(c Controller, name string, arg ...string) *Cmd {
+	return &Cmd{
+		Cmd: exec.Command(name, arg...),
+		c:   c,
+	}
+}
+
+// CmdError is returned by Cmd.Wait when the subprocess was signalled to stop
+// because its task got cancelled.
+// It wraps ErrCancelled or ErrTimedOut.
+type CmdError struct {
+	// Err is the cancellation cause.
+	Err error
+	// ProcessState holds the exit status of the subprocess.
+	ProcessState *os.ProcessState
+}
+
+func (e *CmdError) Error() string {
+	if e.ProcessState == nil {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + " (" + e.ProcessState.String() + ")"
+}
+
+// Unwrap returns the cancellation cause.
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
+// Start starts the subprocess, unless the task has already been cancelled.
+func (cmd *Cmd) Start() error {
+	if err := cmd.c.Checkpoint(); err != nil {
+		return err
+	}
+	if cmd.GracePeriod <= 0 {
+		cmd.GracePeriod = DefaultGracePeriod
+	}
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = cmd.GracePeriod
+	}
+	setpgid(cmd.Cmd)
+	if cmd.Propagate {
+		release, err := Export(cmd.c, cmd.Cmd)
+		if err != nil {
+			return err
+		}
+		cmd.release = release
+	}
+	if err := cmd.Cmd.Start(); err != nil {
+		if cmd.release != nil {
+			cmd.release()
+		}
+		return err
+	}
+	cmd.done = newsignalchan()
+	cmd.watched = newsignalchan()
+
+	go func(cmd *Cmd) {
+		defer close(cmd.watched)
+		select {
+		case <-cmd.done:
+			return
+		case <-cmd.c.sigKill:
+		}
+		cmd.signalled = true
+		terminate(cmd.Process)
+		t := time.NewTimer(cmd.GracePeriod)
+		defer t.Stop()
+		select {
+		case <-cmd.done:
+		case <-t.C:
+			kill(cmd.Process)
+		}
+	}(cmd)
+	return nil
+}
+
+// Wait waits for the subprocess to exit.
+// If it was signalled to stop because the task got cancelled, a *CmdError is
+// returned. A subprocess that exited on its own is reported as by
+// exec.Cmd.Wait, even if the task was cancelled in the meantime.
+func (cmd *Cmd) Wait() error {
+	if cmd.done == nil {
+		return errors.New("Cmd was not started.")
+	}
+	if cmd.waited {
+		return errors.New("Wait was already called.")
+	}
+	cmd.waited = true
+	err := cmd.Cmd.Wait()
+	close(cmd.done)
+	<-cmd.watched
+	kill(cmd.Process)
+	if cmd.release != nil {
+		cmd.release()
+	}
+
+	if cmd.signalled {
+		return &CmdError{cmd.c.kill.cause(), cmd.ProcessState}
+	}
+	return err
+}
+
+// Run starts the subprocess and waits for it to exit.
+func (cmd *Cmd) Run() error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// Output runs the subprocess and returns its standard output, as
+// exec.Cmd.Output does, but under the control of the task.
+func (cmd *Cmd) Output() ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	var stderr *bytes.Buffer
+	if cmd.Stderr == nil {
+		stderr = new(bytes.Buffer)
+		cmd.Stderr = stderr
+	}
+	err := cmd.Run()
+	var ee *exec.ExitError
+	if stderr != nil && errors.As(err, &ee) {
+		ee.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the subprocess and returns its combined standard output
+// and standard error, as exec.Cmd.CombinedOutput does, but under the control of
+// the task.
+func (cmd *Cmd) CombinedOutput() ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := cmd.Run()
+	return b.Bytes(), err
+}
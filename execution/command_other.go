@@ -0,0 +1,21 @@
+//go:build !unix
+
+package execution
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setpgid is a no-op: process groups are not supported on this platform.
+func setpgid(cmd *exec.Cmd) {}
+
+// terminate kills p since it cannot be asked to exit on this platform.
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
+
+// kill kills p.
+func kill(p *os.Process) error {
+	return p.Kill()
+}
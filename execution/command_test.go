@@ -0,0 +1,111 @@
+//go:build unix
+
+package execution
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	cmd := Command(NewController(), "true")
+	if err := cmd.Run(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if err := cmd.Wait(); err == nil {
+		t.Error("Waiting twice for a subprocess should fail.")
+	}
+
+	c := NewController().CancelAfter(Timeout(20 * time.Millisecond))
+	start := time.Now()
+	err := Command(c, "sleep", "10").Run()
+	if !errors.Is(err, ErrTimedOut) {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	var cerr *CmdError
+	if !errors.As(err, &cerr) || cerr.ProcessState == nil {
+		t.Errorf("Expected a *CmdError carrying the exit status but got %#v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("The subprocess should have been terminated early.")
+	}
+}
+
+func TestCommandGracePeriod(t *testing.T) {
+	c := NewController()
+	cmd := Command(c, "sh", "-c", `trap "" TERM; echo ready; sleep 10`)
+	cmd.GracePeriod = 20 * time.Millisecond
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	bufio.NewReader(out).ReadString('\n')
+	c.Cancel()
+
+	if err := cmd.Wait(); !errors.Is(err, ErrCancelled) {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestCommandGrandchildren(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs is not available")
+	}
+	c := NewController()
+	cmd := Command(c, "sh", "-c", `sleep 10 & echo $!; wait`)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(out).ReadString('\n')
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Cancel()
+	cmd.Wait()
+
+	for i := 0; i < 100; i++ {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil || strings.Contains(string(stat), ") Z ") {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("The grandchild process should have been killed.")
+}
+
+func TestCommandOutput(t *testing.T) {
+	out, err := Command(NewController(), "sh", "-c", "echo out; echo err >&2").Output()
+	if err != nil || string(out) != "out\n" {
+		t.Errorf("Expected %q but got %q, %v", "out\n", out, err)
+	}
+	out, err = Command(NewController(), "sh", "-c", "echo out; echo err >&2").CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "err") {
+		t.Errorf("Expected the standard error in the output but got %q, %v", out, err)
+	}
+	_, err = Command(NewController(), "sh", "-c", "echo failed >&2; exit 3").Output()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || string(ee.Stderr) != "failed\n" {
+		t.Errorf("Expected an *exec.ExitError carrying the standard error but got %v", err)
+	}
+
+	c := NewController().CancelAfter(Timeout(20 * time.Millisecond))
+	start := time.Now()
+	_, err = Command(c, "sleep", "10").Output()
+	if !errors.Is(err, ErrTimedOut) || time.Since(start) > 5*time.Second {
+		t.Errorf("Output should be interrupted by the cancellation. Got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package execution
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setpgid makes the subprocess the leader of its own process group.
+func setpgid(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminate asks the process group led by p to exit.
+func terminate(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+// kill kills the process group led by p.
+func kill(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,79 @@
+package execution
+
+import (
+	"time"
+)
+
+// Drain requests the hierarchy of subtasks running in child goroutines to
+// stop gracefully: a subtask is expected to finish its current unit of work
+// and then return, instead of aborting right away as it would upon Cancel.
+//
+// As for Cancel, the request propagates through Spawn to all descendants,
+// including the ones spawned afterwards.
+// A cancelled task is always considered to be draining.
+func (c Controller) Drain() {
+	c.kill.drain()
+}
+
+// DrainUntil requests the subtasks to stop gracefully (see Drain) and
+// arranges for them to be cancelled, with ErrTimedOut, if they have not
+// finished by t.
+func (c Controller) DrainUntil(t time.Time) {
+	c.Drain()
+	d := t.Sub(time.Now())
+	if d <= 0 {
+		c.cancel(ErrTimedOut)
+		return
+	}
+	c.kill.mu.Lock()
+	if c.kill.err == nil && c.kill.drainTimer == nil {
+		c.kill.drainTimer = time.AfterFunc(d, func() { c.cancel(ErrTimedOut) })
+	}
+	c.kill.mu.Unlock()
+}
+
+// Draining returns a channel that is closed when the task has been asked to
+// stop gracefully, or has been cancelled.
+//
+// It is typically checked between two units of work:
+//
+//	for {
+//		select {
+//		case <-c.Draining():
+//			return nil
+//		case job := <-jobs:
+//			process(c, job)
+//		}
+//	}
+func (c Controller) Draining() <-chan struct{} {
+	c.kill.pin()
+	return c.kill.sigDrain
+}
+
+// IsDraining reports whether the task has been asked to stop gracefully, or
+// has been cancelled.
+func (c Controller) IsDraining() bool {
+	select {
+	case <-c.kill.sigDrain:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain closes the draining signal of a killswitch and of its descendants.
+func (ks *killswitch) drain() {
+	ks.mu.Lock()
+	if ks.draining {
+		ks.mu.Unlock()
+		return
+	}
+	ks.draining = true
+	close(ks.sigDrain)
+	children := ks.live()
+	ks.mu.Unlock()
+
+	for _, child := range children {
+		child.drain()
+	}
+}
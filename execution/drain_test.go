@@ -0,0 +1,54 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	c := NewController()
+	child := c.Spawn()
+	grandchild := child.Spawn()
+
+	if child.IsDraining() {
+		t.Error("A new controller should not be draining.")
+	}
+
+	c.Drain()
+	select {
+	case <-grandchild.Draining():
+	default:
+		t.Error("The drain request should have been propagated to the grandchild.")
+	}
+	if err := grandchild.Checkpoint(); err != nil {
+		t.Errorf("Draining should not cancel. Got %v", err)
+	}
+	if !c.Spawn().IsDraining() {
+		t.Error("A controller spawned from a draining one should be draining.")
+	}
+
+	d := NewController()
+	e := d.Spawn()
+	d.Cancel()
+	if !e.IsDraining() {
+		t.Error("A cancelled controller should be draining.")
+	}
+}
+
+func TestDrainUntil(t *testing.T) {
+	c := NewController()
+	child := c.Spawn()
+	c.DrainUntil(Timeout(5 * time.Millisecond))
+
+	if !child.IsDraining() {
+		t.Error("The child should be draining.")
+	}
+	select {
+	case <-child.WasCancelled(nil):
+		if err := child.Checkpoint(); err != ErrTimedOut {
+			t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("The child should have been cancelled once the drain deadline passed.")
+	}
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// The following environment variables make up the protocol used to propagate
+// the cancellation of a task to the subprocesses it launches.
+//
+// The deadline of the parent task, if any, is passed as a number of
+// nanoseconds elapsed since the Unix epoch.
+// The cancellation pipe is passed as the file descriptor of its read end.
+// Before closing the write end, the parent writes a single byte that
+// indicates the cancellation cause. If the pipe is closed without such a
+// byte, the parent is considered dead.
+const (
+	EnvDeadline = "EXECUTION_DEADLINE"
+	EnvCancelFD = "EXECUTION_CANCEL_FD"
+)
+
+const (
+	causeCancelled = 'C'
+	causeTimedOut  = 'T'
+)
+
+// Export configures cmd so that the subprocess it describes can build a
+// Controller that mirrors c by calling FromEnvironment.
+// It must be called before cmd is started.
+//
+// The subprocess inherits the deadline of c, and is notified through a pipe
+// when c is cancelled.
+// The returned release function closes the parent's end of the pipe. It
+// should be called once the subprocess has exited.
+//
+// Pipes cannot be inherited on Windows: there, starting cmd fails.
+func Export(c Controller, cmd *exec.Cmd) (release func(), err error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	if t, ok := c.Deadline(); ok {
+		cmd.Env = append(cmd.Env, EnvDeadline+"="+strconv.FormatInt(t.UnixNano(), 10))
+	}
+	fd := 3 + len(cmd.ExtraFiles)
+	cmd.Env = append(cmd.Env, EnvCancelFD+"="+strconv.Itoa(fd))
+	cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+
+	stop := c.AfterCancel(func() {
+		cause := byte(causeCancelled)
+		if errors.Is(c.kill.cause(), ErrTimedOut) {
+			cause = causeTimedOut
+		}
+		w.Write([]byte{cause})
+		w.Close()
+	})
+
+	return func() {
+		if stop() {
+			w.Close()
+		}
+		r.Close()
+	}, nil
+}
+
+// FromEnvironment returns a root Controller for a program that was launched
+// by a task of another program, following the protocol set up by Export.
+//
+// The Controller inherits the deadline of the parent task, and is cancelled
+// with ErrCancelled when the parent task is cancelled or when the parent
+// process dies.
+// If the program was not launched that way, a new Controller is returned.
+//
+// The protocol environment variables are unset so that they are not
+// inherited by the subprocesses that the program may in turn launch.
+func FromEnvironment() (Controller, error) {
+	c := NewController()
+
+	if s, ok := os.LookupEnv(EnvDeadline); ok {
+		os.Unsetenv(EnvDeadline)
+		ns, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return c, errors.New("Invalid " + EnvDeadline + " value: " + s)
+		}
+		c = c.CancelAfter(time.Unix(0, ns))
+	}
+
+	s, ok := os.LookupEnv(EnvCancelFD)
+	if !ok {
+		return c, nil
+	}
+	os.Unsetenv(EnvCancelFD)
+	fd, err := strconv.Atoi(s)
+	if err != nil || fd < 0 {
+		return c, errors.New("Invalid " + EnvCancelFD + " value: " + s)
+	}
+	f := os.NewFile(uintptr(fd), "cancellation pipe")
+	if f == nil {
+		return c, errors.New("Invalid " + EnvCancelFD + " value: " + s)
+	}
+
+	go func(c Controller, f *os.File) {
+		defer f.Close()
+		buf := make([]byte, 1)
+		n, _ := f.Read(buf)
+		if n == 1 && buf[0] == causeTimedOut {
+			c.cancel(ErrTimedOut)
+			return
+		}
+		c.cancel(ErrCancelled)
+	}(c, f)
+	return c, nil
+}
@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test. It is the subprocess launched by the
+// tests below: it reports the cause for which its Controller was cancelled.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("EXECUTION_HELPER_PROCESS") != "1" {
+		return
+	}
+	c, err := FromEnvironment()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("ready")
+	select {
+	case <-c.WasCancelled(nil):
+		fmt.Println(c.Checkpoint())
+	case <-time.After(5 * time.Second):
+		fmt.Println("not cancelled")
+	}
+	os.Exit(0)
+}
+
+func helperCommand(t *testing.T, c Controller) (*exec.Cmd, *bufio.Reader, func()) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), "EXECUTION_HELPER_PROCESS=1")
+	release, err := Export(c, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(out)
+	if line, _ := r.ReadString('\n'); line != "ready\n" {
+		t.Fatalf("Unexpected output from the helper process: %q", line)
+	}
+	return cmd, r, release
+}
+
+func TestExportCancellation(t *testing.T) {
+	c := NewController()
+	cmd, out, release := helperCommand(t, c)
+	defer release()
+
+	c.Cancel()
+	line, _ := out.ReadString('\n')
+	cmd.Wait()
+	if strings.TrimSpace(line) != ErrCancelled.Error() {
+		t.Errorf("Expected: %v but got: %q", ErrCancelled, line)
+	}
+}
+
+func TestExportDeadline(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(500 * time.Millisecond))
+	cmd, out, release := helperCommand(t, c)
+	defer release()
+
+	line, _ := out.ReadString('\n')
+	cmd.Wait()
+	if strings.TrimSpace(line) != ErrTimedOut.Error() {
+		t.Errorf("Expected: %v but got: %q", ErrTimedOut, line)
+	}
+}
+
+func TestExportParentDeath(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), "EXECUTION_HELPER_PROCESS=1")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Env = append(cmd.Env, EnvCancelFD+"=3")
+	cmd.ExtraFiles = []*os.File{r}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	rd := bufio.NewReader(out)
+	rd.ReadString('\n')
+
+	// Closing the write end without a cause byte is what happens when the
+	// parent process dies.
+	w.Close()
+	line, _ := rd.ReadString('\n')
+	cmd.Wait()
+	if strings.TrimSpace(line) != ErrCancelled.Error() {
+		t.Errorf("Expected: %v but got: %q", ErrCancelled, line)
+	}
+}
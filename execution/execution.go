@@ -14,13 +14,15 @@ package execution
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	// ErrTimedOut is returned when a task did not complete in time.
 	ErrTimedOut = errors.New("Time ran out!")
-	// ErrCancelled is returned when a subtask was aborted by its parent task.
+	// ErrCancelled is returned when a task was aborted. A subtask aborted
+	// because an ancestor ran out of time reports ErrTimedOut instead.
 	ErrCancelled = errors.New("Subtask was aborted!")
 )
 
@@ -28,18 +30,17 @@ var (
 // goroutine at user-defined spots (select statements).
 type Controller struct {
 	sigKill       chan struct{}
-	once          *sync.Once
+	kill          *killswitch
 	parentSigKill chan struct{}
-	deadline      time.Time
 }
 
 // NewController invokes the creation of a new task Controller.
 func NewController() Controller {
+	ks := newkillswitch(nil, false)
 	return Controller{
-		sigKill:       newsignalchan(),
-		once:          new(sync.Once),
+		sigKill:       ks.sigKill,
+		kill:          ks,
 		parentSigKill: none,
-		deadline:      time.Time{},
 	}
 }
 
@@ -52,46 +53,328 @@ func newsignalchan() chan struct{} {
 // and is used to model non-cancellability.
 var none chan struct{}
 
-// Cancel aborts the hierarchy of subtasks running in child goroutines.
-// A task cannot cancel itself. It can only cancel its own subtasks.
-func (c Controller) Cancel() {
+// killswitch holds the cancellation state that is shared by all the copies of
+// a Controller.
+// Each killswitch knows about the killswitches of the subtasks spawned from it
+// so that a cancellation is propagated down the whole hierarchy at once,
+// regardless of whether the intermediate tasks are observing it.
+//
+// A parent only holds weak references to its children (from Go 1.24 onwards,
+// see childref): a subtask that is dropped without being cancelled or finished
+// does not stay attached to its parent. A child is pinned, i.e. strongly referenced, once its signaling
+// channels or callbacks may be used without a reference to its Controller.
+// Code blocking on sigKill should otherwise keep the Controller reachable,
+// typically by reading the cancellation cause afterwards.
+type killswitch struct {
+	sigKill  chan struct{}
+	once     sync.Once
+	self     childref
+	shielded bool
+	isolated bool
+
+	mu         sync.Mutex
+	err        error
+	parent     *killswitch
+	children   map[childref]*killswitch
+	deadline   time.Time
+	expiry     atomic.Int64
+	lapsed     bool
+	timer      *time.Timer
+	afterfuncs map[*func()]struct{}
+	cleanup    []func() error
+	cleanupErr []error
+	cleaning   bool
+	cleaned    chan struct{}
+
+	sigDrain   chan struct{}
+	draining   bool
+	drainTimer *time.Timer
+
+	sigResume chan struct{}
+	suspended bool
+	frozen    bool
+	remaining time.Duration
+
+	watchdog  time.Duration
+	wdTimer   *time.Timer
+	wdStopped bool
+
+	observers map[*Observer]struct{}
+}
+
+// newkillswitch creates a killswitch and attaches it to its parent if any.
+// If the parent has already been triggered, so is the new killswitch.
+//
+// The cancellation of the parent, be it past or later, is not propagated to a
+// shielded killswitch: whoever owns it is responsible for relaying it. A shielded
+// killswitch has no watchdog of its own either, but it passes the watchdog
+// interval on to its children.
+func newkillswitch(parent *killswitch, shielded bool) *killswitch {
+	ks := &killswitch{
+		sigKill:  newsignalchan(),
+		parent:   parent,
+		cleaned:  newsignalchan(),
+		sigDrain: newsignalchan(),
+		shielded: shielded,
+	}
+	if parent == nil {
+		return ks
+	}
+	parent.mu.Lock()
+	if parent.err != nil && !shielded {
+		err := parent.err
+		parent.mu.Unlock()
+		ks.trigger(err, false)
+		return ks
+	}
+	if parent.draining {
+		ks.draining = true
+		close(ks.sigDrain)
+	}
+	ks.suspended = parent.suspended
+	if parent.children == nil {
+		parent.children = make(map[childref]*killswitch)
+	}
+	ks.self = refer(ks)
+	parent.children[ks.self] = nil
+	interval := parent.watchdog
+	parent.mu.Unlock()
+	collectable(parent, ks)
+	ks.watch(interval)
+	return ks
+}
+
+// forget removes a child that has been garbage collected.
+func (ks *killswitch) forget(child childref) {
+	ks.mu.Lock()
+	delete(ks.children, child)
+	ks.mu.Unlock()
+}
+
+// pin makes the ancestors of ks hold strong references to it, so that it is
+// not collected while its signaling channels or callbacks are in use, even if
+// its Controller is not referenced anymore.
+// A pinned killswitch stays attached to its parent until it is triggered.
+func (ks *killswitch) pin() {
+	for ; ks.parent != nil; ks = ks.parent {
+		p := ks.parent
+		p.mu.Lock()
+		strong, ok := p.children[ks.self]
+		if !ok || strong != nil {
+			// Detached, or already pinned along with its ancestors.
+			p.mu.Unlock()
+			return
+		}
+		p.children[ks.self] = ks
+		p.mu.Unlock()
+	}
+}
+
+// live returns the children of ks that have not been collected.
+// ks.mu must be held.
+func (ks *killswitch) live() []*killswitch {
+	children := make([]*killswitch, 0, len(ks.children))
+	for r, strong := range ks.children {
+		if strong == nil {
+			strong = deref(r)
+		}
+		if strong != nil {
+			children = append(children, strong)
+		}
+	}
+	return children
+}
+
+// trigger closes the signaling channel of a killswitch, recording err as the
+// cause, and propagates the cancellation to the descendants.
+// Only the first call has an effect.
+func (ks *killswitch) trigger(err error, detach bool) {
+	ks.once.Do(func() {
+		ks.mu.Lock()
+		ks.err = err
+		close(ks.sigKill)
+		if !ks.draining {
+			ks.draining = true
+			close(ks.sigDrain)
+		}
+		children := ks.live()
+		ks.children = nil
+		afterfuncs := ks.afterfuncs
+		ks.afterfuncs = nil
+		cleanup := ks.cleanup
+		ks.cleanup = nil
+		ks.cleaning = len(cleanup) > 0
+		if !ks.cleaning {
+			close(ks.cleaned)
+		}
+		if ks.timer != nil {
+			ks.timer.Stop()
+		}
+		if ks.drainTimer != nil {
+			ks.drainTimer.Stop()
+		}
+		if ks.wdTimer != nil {
+			ks.wdTimer.Stop()
+		}
+		ks.mu.Unlock()
+
+		for _, child := range children {
+			if !child.shielded {
+				child.trigger(err, false)
+			}
+		}
+		for f := range afterfuncs {
+			go (*f)()
+		}
+		if len(cleanup) > 0 {
+			go ks.clean(cleanup)
+		}
+
+		if detach && ks.parent != nil {
+			ks.parent.mu.Lock()
+			delete(ks.parent.children, ks.self)
+			ks.parent.mu.Unlock()
+		}
+	})
+}
+
+// cause returns the reason for which the killswitch was triggered.
+// It must only be called once sigKill is known to be closed.
+func (ks *killswitch) cause() error {
+	return ks.err
+}
+
+// cancel dispatches a cancellation signal whose cause is err.
+func (c Controller) cancel(err error) {
 	select {
 	case <-c.sigKill:
 	default:
-		c.once.Do(func() {
-			close(c.sigKill)
-		})
+		c.kill.trigger(err, true)
 	}
 }
 
+// Cancel aborts the hierarchy of subtasks running in child goroutines.
+// A task cannot cancel itself. It can only cancel its own subtasks.
+func (c Controller) Cancel() {
+	c.cancel(ErrCancelled)
+}
+
 // Spawn creates a child Controller.
 // Spawned controllers are used by subtasks running in child goroutines.
 func (c Controller) Spawn() Controller {
+	ks := newkillswitch(c.kill, false)
 	return Controller{
-		sigKill:       newsignalchan(),
-		once:          new(sync.Once),
+		sigKill:       ks.sigKill,
+		kill:          ks,
 		parentSigKill: c.sigKill,
-		deadline:      c.deadline,
 	}
 }
 
+// spawnShielded is similar to Spawn, except that the cancellation of c is not
+// propagated to the child, which its owner must cancel itself. Deadlines,
+// draining, pausing and the watchdog interval are inherited as usual.
+func (c Controller) spawnShielded() Controller {
+	ks := newkillswitch(c.kill, true)
+	return Controller{
+		sigKill:       ks.sigKill,
+		kill:          ks,
+		parentSigKill: c.sigKill,
+	}
+}
+
+// spawnIsolated is similar to spawnShielded, except that the deadlines of the
+// ancestors of c do not apply to the child either.
+func (c Controller) spawnIsolated() Controller {
+	child := c.spawnShielded()
+	child.kill.isolated = true
+	return child
+}
+
+// spawnUntil is equivalent to c.Spawn().CancelAfter(t), without the
+// intermediate Controller that would remain attached to c.
+func (c Controller) spawnUntil(t time.Time) Controller {
+	child := c.Spawn()
+	child.kill.arm(t)
+	return child
+}
+
 // CancelAfter will clone and alter a Controller, providing a date
 // for the automatic dispatch of a cancellation signal.
 // A date that has already passed implies immediate cancellation.
 //
 // It enables sibling tasks with different cancellation policies.
 func (c Controller) CancelAfter(t time.Time) Controller {
-	c.deadline = t
-	c.sigKill = newsignalchan()
-	c.once = new(sync.Once)
+	ks := newkillswitch(c.kill.parent, false)
+	ks.watch(c.kill.interval())
+	ks.arm(t)
+	c.kill = ks
+	c.sigKill = ks.sigKill
 	return c
 }
 
-func (c Controller) timedout() *time.Timer {
-	if !c.deadline.IsZero() {
-		return time.NewTimer(c.deadline.UTC().Sub(time.Now().UTC()))
+// arm schedules the automatic cancellation of a killswitch at t.
+func (ks *killswitch) arm(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	d := t.Sub(time.Now())
+	if d <= 0 {
+		ks.trigger(ErrTimedOut, true)
+		return
+	}
+	ks.mu.Lock()
+	if ks.err == nil {
+		ks.setDeadline(t)
+		ks.schedule(d)
+	}
+	ks.mu.Unlock()
+}
+
+// setDeadline records the deadline of a killswitch.
+// ks.mu must be held.
+func (ks *killswitch) setDeadline(t time.Time) {
+	ks.deadline = t
+	if t.IsZero() {
+		ks.expiry.Store(0)
+		return
+	}
+	ks.expiry.Store(t.UnixNano())
+}
+
+// expired checks whether the deadline of ks, or of one of its ancestors, has
+// passed. If so, the corresponding killswitch is made to lapse right away
+// instead of waiting for its timer to fire.
+func (ks *killswitch) expired() {
+	now := time.Now().UnixNano()
+	for ; ks != nil; ks = ks.parent {
+		if e := ks.expiry.Load(); e != 0 && now >= e {
+			ks.lapse()
+			return
+		}
+		if ks.isolated {
+			return
+		}
+	}
+}
+
+// Deadline returns the time at which the task will be cancelled automatically,
+// i.e. the earliest of its own deadline and of the deadlines of its ancestors.
+// Within Shield, the deadlines of the ancestors of the shielded task do not
+// apply.
+// ok is false if no deadline is set.
+func (c Controller) Deadline() (deadline time.Time, ok bool) {
+	for ks := c.kill; ks != nil; ks = ks.parent {
+		ks.mu.Lock()
+		d := ks.deadline
+		ks.mu.Unlock()
+		if !d.IsZero() && (!ok || d.Before(deadline)) {
+			deadline, ok = d, true
+		}
+		if ks.isolated {
+			break
+		}
 	}
-	return nil
+	return deadline, ok
 }
 
 // WasCancelled returns a channel which allows to be notified
@@ -105,71 +388,26 @@ func (c Controller) timedout() *time.Timer {
 //
 // The reasons for the signal to trigger can be twofold:
 // the task ran out of time, or its parent task cancelled it.
+// The reason sent is the cause recorded for the task when the cancellation
+// reached it: a subtask whose parent timed out receives ErrTimedOut, not
+// ErrCancelled.
+//
+// No goroutine is started before the task is cancelled, but each call with a
+// non-nil error channel registers a delivery that is kept until then. Calling
+// it on every iteration of a loop is therefore best avoided: Checkpoint returns
+// the same reason.
+//
+// Since the returned channel may outlive any reference to c, the task stays
+// attached to its parent until it is cancelled or finished.
 func (c Controller) WasCancelled(errCh chan error) <-chan struct{} {
-
-	timer := c.timedout()
-	var expired <-chan time.Time
-	if timer != nil {
-		expired = timer.C
-	}
-
-	go func(c Controller, timer *time.Timer, expired <-chan time.Time, errchan chan error) {
-		if c.parentSigKill != none { // i.e. it is a spawned task (aka subtask, not top level.)
-			select {
-			case <-c.parentSigKill:
-				c.Cancel()
-				if timer != nil {
-					timer.Stop()
-				}
-				if errchan != nil {
-					errchan <- ErrCancelled
-				}
-			case <-expired:
-				c.Cancel()
-				if errchan != nil {
-					errchan <- ErrTimedOut
-				}
-			}
-		} else { // this is a root task i.e. it has no parent.
-			if expired != nil {
-				select {
-				case <-expired:
-					c.Cancel()
-					if errchan != nil {
-						errchan <- ErrTimedOut
-					}
-				}
-			}
-		}
-
-	}(c, timer, expired, errCh)
-
-	// Use of select needed here to make sure all channel communications
-	// are synchronized.
-	// Especially with the goroutine we launched above.
-	if c.parentSigKill != none {
-		select {
-		case <-c.parentSigKill:
-			c.Cancel()
-			if timer != nil {
-				timer.Stop()
-			}
-			return c.sigKill
-		case <-expired:
-			c.Cancel()
-			return c.sigKill
-		default:
-			return c.sigKill
-		}
-	} else {
-		select {
-		case <-expired:
-			c.Cancel()
-			return c.sigKill
-		default:
-			return c.sigKill
-		}
+	c.kill.pin()
+	if errCh != nil {
+		ks := c.kill
+		c.AfterCancel(func() {
+			errCh <- ks.cause()
+		})
 	}
+	return c.sigKill
 }
 
 // Panic will unwind the current goroutine, but not before sending a cancellation
@@ -257,3 +495,10 @@ func (c Context) CancelAfter(t time.Time) Context {
 	c.Controller = c.Controller.CancelAfter(t)
 	return c
 }
+
+// CancelIfStalled will clone and alter a Context, providing a watchdog that
+// cancels the task if it stops making progress.
+func (c Context) CancelIfStalled(interval time.Duration) Context {
+	c.Controller = c.Controller.CancelIfStalled(interval)
+	return c
+}
@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"runtime"
 	"testing"
 	"time"
 )
@@ -246,8 +247,8 @@ func TestCancelAfter(t *testing.T) {
 	waittime := Timeout(wait)
 	v := c.CancelAfter(deadline).Spawn()
 
-	if v.deadline != deadline {
-		t.Errorf("CancelAfter was not set correctly, expected %v but got %v", deadline, v.deadline)
+	if d, _ := v.Deadline(); d != deadline {
+		t.Errorf("CancelAfter was not set correctly, expected %v but got %v", deadline, d)
 	}
 
 	select {
@@ -330,3 +331,35 @@ func TestTimeoutFunc(t *testing.T) {
 
 	}
 }
+
+// attached returns the number of subtasks attached to the task controlled by c.
+func attached(c Controller) int {
+	c.kill.mu.Lock()
+	defer c.kill.mu.Unlock()
+	return len(c.kill.children)
+}
+
+func TestWasCancelledReason(t *testing.T) {
+	before := runtime.NumGoroutine()
+	root := NewController()
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		root.WasCancelled(errs)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("No goroutine should wait for a task that was not cancelled: %v before, %v after", before, after)
+	}
+
+	parent := root.Spawn().CancelAfter(Timeout(10 * time.Millisecond))
+	child := parent.Spawn()
+	errCh := make(chan error, 1)
+	child.WasCancelled(errCh)
+	select {
+	case err := <-errCh:
+		if err != ErrTimedOut {
+			t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+		}
+	case <-time.After(time.Second):
+		t.Error("The reason for the cancellation should have been sent.")
+	}
+}
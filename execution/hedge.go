@@ -0,0 +1,90 @@
+package execution
+
+import (
+	"errors"
+	"time"
+)
+
+// Hedge runs op and, if it has not succeeded after schedule[0], runs it again
+// concurrently as a backup attempt, then again after schedule[1], and so on.
+// The delays of the schedule are relative to the start of Hedge. An attempt
+// that fails triggers the next one right away.
+//
+// Each attempt runs under its own child of c. The result of the first attempt
+// that succeeds is returned, and the other attempts are cancelled.
+// If every attempt fails, their errors are joined.
+// If c is cancelled first, the cancellation cause is returned and all the
+// attempts are cancelled. Since the attempts are spawned from c, none of them
+// outlives the deadline of c.
+func Hedge[T any](c Controller, schedule []time.Duration, op func(Controller) (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	var zero T
+	n := len(schedule) + 1
+	results := make(chan result, n)
+	attempts := make([]Controller, 0, n)
+	defer func() {
+		for _, a := range attempts {
+			a.Cancel()
+		}
+	}()
+
+	launch := func() {
+		a := c.Spawn()
+		attempts = append(attempts, a)
+		go func() {
+			v, err := op(a)
+			results <- result{v, err}
+		}()
+	}
+
+	if err := c.Checkpoint(); err != nil {
+		return zero, err
+	}
+	start := time.Now()
+	launch()
+
+	var timer <-chan time.Time
+	var t *time.Timer
+	next := func() {
+		if t != nil {
+			t.Stop()
+			timer = nil
+		}
+		if len(attempts) < n {
+			t = time.NewTimer(schedule[len(attempts)-1] - time.Since(start))
+			timer = t.C
+		}
+	}
+	next()
+	defer func() {
+		if t != nil {
+			t.Stop()
+		}
+	}()
+
+	var errs []error
+	for {
+		select {
+		case r := <-results:
+			if r.err == nil {
+				return r.v, nil
+			}
+			errs = append(errs, r.err)
+			if len(errs) == n {
+				return zero, errors.Join(errs...)
+			}
+			if len(attempts) < n {
+				launch()
+				next()
+			}
+		case <-timer:
+			launch()
+			next()
+		case <-c.sigKill:
+			return zero, c.kill.cause()
+		}
+	}
+}
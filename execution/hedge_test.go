@@ -0,0 +1,54 @@
+package execution
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var started, cancelled atomic.Int32
+	v, err := Hedge(NewController(), []time.Duration{5 * time.Millisecond, 10 * time.Millisecond}, func(c Controller) (int, error) {
+		n := started.Add(1)
+		if n == 1 {
+			// The first attempt is slow.
+			if err := c.Sleep(time.Second); err != nil {
+				cancelled.Add(1)
+				return 0, err
+			}
+		}
+		return int(n), nil
+	})
+	if err != nil || v != 2 {
+		t.Errorf("Expected the backup attempt to win. Got %v, %v", v, err)
+	}
+	for i := 0; i < 100 && cancelled.Load() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if started.Load() != 2 || cancelled.Load() != 1 {
+		t.Errorf("Expected 2 attempts and 1 cancellation but got %v and %v", started.Load(), cancelled.Load())
+	}
+}
+
+func TestHedgeFailures(t *testing.T) {
+	errFailed := errors.New("failed")
+	calls := 0
+	_, err := Hedge(NewController(), []time.Duration{time.Hour, time.Hour}, func(c Controller) (int, error) {
+		calls++
+		return 0, errFailed
+	})
+	if !errors.Is(err, errFailed) || calls != 3 {
+		t.Errorf("Expected every attempt to fail right away. Got %v after %v calls", err, calls)
+	}
+}
+
+func TestHedgeDeadline(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(5 * time.Millisecond))
+	_, err := Hedge(c, []time.Duration{time.Millisecond}, func(c Controller) (int, error) {
+		return 0, c.Sleep(time.Second)
+	})
+	if err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+}
@@ -0,0 +1,233 @@
+package execution
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// aLongTimeAgo is a deadline in the past. Setting it on a resource makes its
+// pending and future blocking operations return immediately.
+var aLongTimeAgo = time.Unix(1, 0)
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// interrupt arranges for the blocking operations pending on v to return once
+// c has been cancelled.
+// If v supports read (resp. write) deadlines, an expired deadline is set.
+// Otherwise, v is closed if it is an io.Closer.
+func interrupt(c Controller, v interface{}, read bool) (stop func() bool) {
+	return c.AfterCancel(func() {
+		if read {
+			if d, ok := v.(readDeadliner); ok && d.SetReadDeadline(aLongTimeAgo) == nil {
+				return
+			}
+		} else {
+			if d, ok := v.(writeDeadliner); ok && d.SetWriteDeadline(aLongTimeAgo) == nil {
+				return
+			}
+		}
+		if cl, ok := v.(io.Closer); ok {
+			cl.Close()
+		}
+	})
+}
+
+// ioerr translates the error returned by an I/O operation performed on behalf
+// of the task controlled by c.
+// If the task was cancelled, the cancellation cause is returned instead.
+func ioerr(c Controller, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cerr := c.Checkpoint(); cerr != nil {
+		return cerr
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrTimedOut
+	}
+	return err
+}
+
+// Reader wraps an io.Reader so that its Read method honours a Controller.
+//
+// Once the Controller is cancelled, Read returns ErrCancelled or ErrTimedOut.
+// A Read call that is blocked at that moment is interrupted: by setting an
+// expired read deadline if the underlying reader supports it (net.Conn,
+// os.File pipes...), or by closing the underlying reader if it is an io.Closer.
+type Reader struct {
+	c    Controller
+	r    io.Reader
+	stop func() bool
+}
+
+// NewReader returns a Reader reading from r on behalf of the task controlled
+// by c.
+// If r supports read deadlines, the deadline of c is applied to it.
+func NewReader(c Controller, r io.Reader) *Reader {
+	if t, ok := c.Deadline(); ok {
+		if d, ok := r.(readDeadliner); ok {
+			d.SetReadDeadline(t)
+		}
+	}
+	return &Reader{c, r, interrupt(c, r, true)}
+}
+
+// Read implements io.Reader.
+func (r *Reader) Read(p []byte) (int, error) {
+	if err := r.c.Checkpoint(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		return n, err
+	}
+	return n, ioerr(r.c, err)
+}
+
+// Close detaches the Reader from its Controller and closes the underlying
+// reader if it is an io.Closer.
+func (r *Reader) Close() error {
+	r.stop()
+	if cl, ok := r.r.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// Writer wraps an io.Writer so that its Write method honours a Controller.
+// It is the counterpart of Reader for write operations.
+type Writer struct {
+	c    Controller
+	w    io.Writer
+	stop func() bool
+}
+
+// NewWriter returns a Writer writing to w on behalf of the task controlled
+// by c.
+// If w supports write deadlines, the deadline of c is applied to it.
+func NewWriter(c Controller, w io.Writer) *Writer {
+	if t, ok := c.Deadline(); ok {
+		if d, ok := w.(writeDeadliner); ok {
+			d.SetWriteDeadline(t)
+		}
+	}
+	return &Writer{c, w, interrupt(c, w, false)}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if err := w.c.Checkpoint(); err != nil {
+		return 0, err
+	}
+	n, err := w.w.Write(p)
+	return n, ioerr(w.c, err)
+}
+
+// Close detaches the Writer from its Controller and closes the underlying
+// writer if it is an io.Closer.
+func (w *Writer) Close() error {
+	w.stop()
+	if cl, ok := w.w.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// Conn wraps a net.Conn so that its Read and Write methods honour a Controller.
+type Conn struct {
+	net.Conn
+	c    Controller
+	stop func() bool
+}
+
+// NewConn returns a Conn using conn on behalf of the task controlled by c.
+// The deadline of c is applied to conn, and conn is closed once c is
+// cancelled.
+func NewConn(c Controller, conn net.Conn) *Conn {
+	if t, ok := c.Deadline(); ok {
+		conn.SetDeadline(t)
+	}
+	stop := c.AfterCancel(func() { conn.Close() })
+	return &Conn{conn, c, stop}
+}
+
+// Read implements net.Conn.
+func (c *Conn) Read(p []byte) (int, error) {
+	if err := c.c.Checkpoint(); err != nil {
+		return 0, err
+	}
+	n, err := c.Conn.Read(p)
+	if err == io.EOF {
+		return n, err
+	}
+	return n, ioerr(c.c, err)
+}
+
+// Write implements net.Conn.
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.c.Checkpoint(); err != nil {
+		return 0, err
+	}
+	n, err := c.Conn.Write(p)
+	return n, ioerr(c.c, err)
+}
+
+// Close detaches the Conn from its Controller and closes the connection.
+func (c *Conn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// Copy copies from src to dst until either EOF is reached on src, an error
+// occurs, or the task controlled by c is cancelled.
+// It returns the number of bytes that were transferred, even when the copy was
+// stopped mid-stream, in which case err is the cancellation cause.
+//
+// Blocking reads and writes are interrupted as described for Reader.
+func Copy(c Controller, dst io.Writer, src io.Reader) (written int64, err error) {
+	return CopyBuffer(c, dst, src, nil)
+}
+
+// CopyBuffer is identical to Copy except that it stages through the provided
+// buffer if one is required, rather than allocating a temporary one.
+func CopyBuffer(c Controller, dst io.Writer, src io.Reader, buf []byte) (written int64, err error) {
+	if len(buf) == 0 {
+		buf = make([]byte, 32*1024)
+	}
+	defer interrupt(c, src, true)()
+	defer interrupt(c, dst, false)()
+
+	for {
+		if err = c.Checkpoint(); err != nil {
+			return written, err
+		}
+		nr, rerr := src.Read(buf)
+		if nr > 0 {
+			nw, werr := dst.Write(buf[:nr])
+			if nw > 0 {
+				written += int64(nw)
+			}
+			if werr != nil {
+				return written, ioerr(c, werr)
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if rerr == io.EOF {
+			return written, nil
+		}
+		if rerr != nil {
+			return written, ioerr(c, rerr)
+		}
+	}
+}
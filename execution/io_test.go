@@ -0,0 +1,99 @@
+package execution
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingReader blocks on Read until it is closed.
+type blockingReader struct {
+	closed chan struct{}
+}
+
+func (b blockingReader) Read(p []byte) (int, error) {
+	<-b.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (b blockingReader) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestReaderCancellation(t *testing.T) {
+	c := NewController()
+	r := NewReader(c.Spawn(), blockingReader{make(chan struct{})})
+
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		c.Cancel()
+	}()
+	if _, err := r.Read(make([]byte, 8)); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestReaderDeadline(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pw.Close()
+
+	r := NewReader(NewController().CancelAfter(Timeout(5*time.Millisecond)), pr)
+	defer r.Close()
+	if _, err := r.Read(make([]byte, 8)); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+}
+
+func TestConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	c := NewController()
+	conn := NewConn(c, client)
+	go server.Write([]byte("ping"))
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("Expected to read ping but got %q, %v", buf, err)
+	}
+
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		c.Cancel()
+	}()
+	if _, err := conn.Read(buf); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	var dst bytes.Buffer
+	n, err := Copy(NewController(), &dst, strings.NewReader("hello world"))
+	if n != 11 || err != nil || dst.String() != "hello world" {
+		t.Errorf("Unexpected copy outcome: %v, %v, %q", n, err, dst.String())
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		client.Write([]byte("partial"))
+	}()
+
+	dst.Reset()
+	c := NewController().CancelAfter(Timeout(10 * time.Millisecond))
+	n, err = Copy(c, &dst, server)
+	if err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	if n != 7 {
+		t.Errorf("Expected 7 bytes to have been transferred but got %v", n)
+	}
+}
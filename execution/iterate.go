@@ -0,0 +1,241 @@
+package execution
+
+import (
+	"iter"
+	"time"
+)
+
+// An iterator cannot return an error. The adapters below therefore return,
+// along with the iterator, a function reporting why the last iteration
+// stopped: nil if the underlying sequence was exhausted or the consumer broke
+// out of the loop, the cancellation cause or a producer error otherwise.
+//
+//	seq, stopped := execution.Seq(c, rows)
+//	for row := range seq {
+//		// processing...
+//	}
+//	if err := stopped(); err != nil {
+//		return err
+//	}
+
+// Seq wraps seq so that iteration stops as soon as c is cancelled.
+// Cancellation is checked before each element: a sequence that blocks while
+// producing an element is only interrupted once it yields. Use Generate for
+// producers that have to be interrupted.
+func Seq[T any](c Controller, seq iter.Seq[T]) (iter.Seq[T], func() error) {
+	var err error
+	return func(yield func(T) bool) {
+			err = c.Checkpoint()
+			if err != nil {
+				return
+			}
+			for v := range seq {
+				if err = c.Checkpoint(); err != nil || !yield(v) {
+					return
+				}
+			}
+		}, func() error {
+			return err
+		}
+}
+
+// Seq2 is the iter.Seq2 counterpart of Seq.
+func Seq2[K, V any](c Controller, seq iter.Seq2[K, V]) (iter.Seq2[K, V], func() error) {
+	var err error
+	return func(yield func(K, V) bool) {
+			err = c.Checkpoint()
+			if err != nil {
+				return
+			}
+			for k, v := range seq {
+				if err = c.Checkpoint(); err != nil || !yield(k, v) {
+					return
+				}
+			}
+		}, func() error {
+			return err
+		}
+}
+
+// Chan returns an iterator over the values received from ch, until ch is
+// closed or c is cancelled. Unlike Seq, a pending receive is interrupted by
+// the cancellation.
+func Chan[T any](c Controller, ch <-chan T) (iter.Seq[T], func() error) {
+	var err error
+	return func(yield func(T) bool) {
+			for {
+				var v T
+				var ok bool
+				v, ok, err = Recv(c, ch)
+				if err != nil || !ok || !yield(v) {
+					return
+				}
+			}
+		}, func() error {
+			return err
+		}
+}
+
+// Generate returns an iterator over the values produced by producer, which
+// runs in its own goroutine, under a child of c, each time the iterator is
+// ranged over. Produced values are buffered up to buffer.
+//
+// The producer hands over values with yield, which returns the cancellation
+// cause once the consumer has broken out of the loop or c has been cancelled;
+// the producer should then return. The iteration does not complete before the
+// producer has returned.
+func Generate[T any](c Controller, buffer int, producer func(c Controller, yield func(T) error) error) (iter.Seq[T], func() error) {
+	var err error
+	return func(yield func(T) bool) {
+			p := c.Spawn()
+			ch := make(chan T, buffer)
+			done := newsignalchan()
+			var perr error
+			go func() {
+				defer close(done)
+				defer close(ch)
+				perr = p.Finish(producer(p, func(v T) error {
+					return Send(p, ch, v)
+				}))
+			}()
+
+			// The producer is stopped and waited for however the iteration
+			// ends, including when the loop body panics.
+			defer func() {
+				p.Cancel()
+				for range ch {
+				}
+				<-done
+			}()
+
+			err = nil
+			for {
+				v, ok, cerr := Recv(c, ch)
+				if cerr != nil {
+					err = cerr
+					return
+				}
+				if !ok {
+					<-done
+					err = perr
+					return
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		}, func() error {
+			return err
+		}
+}
+
+// Batch returns an iterator over batches of at most size values received
+// from ch. A batch is handed over as soon as it is full, or once linger has
+// elapsed since its first value was received, whichever comes first; a zero
+// linger only hands over full batches and the final one.
+//
+// When c is cancelled, the pending partial batch is still handed over before
+// the iteration stops, so that no received value is lost. The iterator hands
+// over a new slice for each batch.
+func Batch[T any](c Controller, ch <-chan T, size int, linger time.Duration) (iter.Seq[[]T], func() error) {
+	if size < 1 {
+		panic("Batch size must be positive.")
+	}
+	var err error
+	return func(yield func([]T) bool) {
+			err = c.Checkpoint()
+			if err != nil {
+				return
+			}
+			var batch []T
+			var timer *time.Timer
+			var expired <-chan time.Time
+			defer func() {
+				if timer != nil {
+					timer.Stop()
+				}
+			}()
+			flush := func() bool {
+				if timer != nil {
+					timer.Stop()
+					expired = nil
+				}
+				if len(batch) == 0 {
+					return true
+				}
+				b := batch
+				batch = nil
+				return yield(b)
+			}
+
+			for {
+				select {
+				case v, ok := <-ch:
+					if !ok {
+						flush()
+						return
+					}
+					batch = append(batch, v)
+					if len(batch) == 1 && linger > 0 {
+						if timer == nil {
+							timer = time.NewTimer(linger)
+						} else {
+							timer.Reset(linger)
+						}
+						expired = timer.C
+					}
+					if len(batch) == size && !flush() {
+						return
+					}
+				case <-expired:
+					expired = nil
+					if !flush() {
+						return
+					}
+				case <-c.sigKill:
+					err = c.kill.cause()
+					flush()
+					return
+				}
+			}
+		}, func() error {
+			return err
+		}
+}
+
+// Window returns an iterator over the sliding windows of size consecutive
+// values of seq, the start of each window being step values after the start
+// of the previous one. A trailing window that is not full is not handed over.
+// Cancellation is checked as in Seq. The iterator hands over a new slice for
+// each window.
+func Window[T any](c Controller, seq iter.Seq[T], size, step int) (iter.Seq[[]T], func() error) {
+	if size < 1 || step < 1 {
+		panic("Window size and step must be positive.")
+	}
+	values, stopped := Seq(c, seq)
+	return func(yield func([]T) bool) {
+		var window []T
+		skip := 0
+		for v := range values {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			window = append(window, v)
+			if len(window) < size {
+				continue
+			}
+			w := make([]T, size)
+			copy(w, window)
+			if !yield(w) {
+				return
+			}
+			if step < size {
+				window = append(window[:0], window[step:]...)
+			} else {
+				window = window[:0]
+				skip = step - size
+			}
+		}
+	}, stopped
+}
@@ -0,0 +1,163 @@
+package execution
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSeq(t *testing.T) {
+	c := NewController()
+	seq, stopped := Seq(c, slices.Values([]int{1, 2, 3, 4}))
+	var got []int
+	for v := range seq {
+		got = append(got, v)
+		if v == 2 {
+			c.Cancel()
+		}
+	}
+	if !slices.Equal(got, []int{1, 2}) || stopped() != ErrCancelled {
+		t.Errorf("Expected [1 2] and %v but got %v and %v", ErrCancelled, got, stopped())
+	}
+
+	seq2, stopped := Seq2(NewController(), slices.All([]string{"a", "b"}))
+	n := 0
+	for range seq2 {
+		n++
+	}
+	if n != 2 || stopped() != nil {
+		t.Errorf("Expected 2 elements and no error but got %v and %v", n, stopped())
+	}
+}
+
+func TestChan(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(20 * time.Millisecond))
+	ch := make(chan int, 1)
+	ch <- 1
+	seq, stopped := Chan(c, ch)
+	n := 0
+	for range seq {
+		n++
+	}
+	if n != 1 || stopped() != ErrTimedOut {
+		t.Errorf("Expected 1 element and %v but got %v and %v", ErrTimedOut, n, stopped())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	exited := make(chan error, 1)
+	seq, stopped := Generate(NewController(), 0, func(c Controller, yield func(int) error) error {
+		for i := 0; ; i++ {
+			if err := yield(i); err != nil {
+				exited <- err
+				return err
+			}
+		}
+	})
+	for v := range seq {
+		if v == 5 {
+			break
+		}
+	}
+	select {
+	case err := <-exited:
+		if err != ErrCancelled {
+			t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+		}
+	default:
+		t.Error("The producer should have returned when the consumer broke out of the loop.")
+	}
+	if err := stopped(); err != nil {
+		t.Errorf("Breaking out of the loop should not be an error. Got %v", err)
+	}
+
+	errFailed := errors.New("failed")
+	seq, stopped = Generate(NewController(), 2, func(c Controller, yield func(int) error) error {
+		yield(1)
+		return errFailed
+	})
+	for range seq {
+	}
+	if stopped() != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, stopped())
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		for i := 0; i < 5; i++ {
+			ch <- i
+		}
+		time.Sleep(100 * time.Millisecond)
+		ch <- 5
+		close(ch)
+	}()
+	seq, stopped := Batch(NewController(), ch, 2, 20*time.Millisecond)
+	var got [][]int
+	for b := range seq {
+		got = append(got, b)
+	}
+	want := [][]int{{0, 1}, {2, 3}, {4}, {5}}
+	if !slices.EqualFunc(got, want, slices.Equal) || stopped() != nil {
+		t.Errorf("Expected %v but got %v (%v)", want, got, stopped())
+	}
+
+	c := NewController().CancelAfter(Timeout(20 * time.Millisecond))
+	ch = make(chan int, 1)
+	ch <- 1
+	seq, stopped = Batch(c, ch, 10, 0)
+	got = nil
+	for b := range seq {
+		got = append(got, b)
+	}
+	if len(got) != 1 || stopped() != ErrTimedOut {
+		t.Errorf("The partial batch should be handed over on timeout. Got %v (%v)", got, stopped())
+	}
+}
+
+func TestWindow(t *testing.T) {
+	seq, _ := Window(NewController(), slices.Values([]int{1, 2, 3, 4, 5}), 3, 1)
+	var got [][]int
+	for w := range seq {
+		got = append(got, w)
+	}
+	want := [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+
+	seq, _ = Window(NewController(), slices.Values([]int{1, 2, 3, 4, 5, 6, 7}), 2, 3)
+	got = nil
+	for w := range seq {
+		got = append(got, w)
+	}
+	want = [][]int{{1, 2}, {4, 5}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+}
+
+func TestGeneratePanic(t *testing.T) {
+	exited := make(chan struct{})
+	seq, _ := Generate(NewController(), 0, func(c Controller, yield func(int) error) error {
+		defer close(exited)
+		for i := 0; ; i++ {
+			if err := yield(i); err != nil {
+				return err
+			}
+		}
+	})
+	func() {
+		defer func() { recover() }()
+		for range seq {
+			panic("boom")
+		}
+	}()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Error("The producer should have returned when the loop body panicked.")
+	}
+}
@@ -0,0 +1,80 @@
+package execution
+
+import (
+	"time"
+)
+
+// SetDeadline changes the deadline of the task controlled by c in place, be it
+// to extend or to shorten it. Unlike CancelAfter, the Controller keeps its
+// identity: its copies, its parent and its subtasks are all affected.
+//
+// The deadline is capped by the deadlines of the ancestors of c. A zero t
+// removes the deadline of c, leaving only the cap.
+// It returns the resulting deadline of the task (see Deadline), or the
+// cancellation cause if the task has already been cancelled, in which case
+// the deadline cannot be changed anymore.
+//
+// A LeaseRenewed event is emitted to the observers of c.
+func (c Controller) SetDeadline(t time.Time) (time.Time, error) {
+	ks := c.kill
+	if limit, ok := ks.parentDeadline(); ok && !t.IsZero() && t.After(limit) {
+		t = limit
+	}
+
+	ks.mu.Lock()
+	if ks.err != nil || ks.lapsed {
+		err := ks.err
+		ks.mu.Unlock()
+		if err == nil {
+			err = ErrTimedOut // lapsing
+		}
+		return time.Time{}, err
+	}
+	ks.setDeadline(t)
+	if t.IsZero() {
+		if ks.timer != nil {
+			ks.timer.Stop()
+		}
+		ks.frozen = false
+	} else {
+		ks.schedule(time.Until(t))
+	}
+	ks.mu.Unlock()
+
+	deadline, _ := c.Deadline()
+	ks.emit(Event{Kind: LeaseRenewed, Deadline: deadline})
+	return deadline, nil
+}
+
+// Renew sets the deadline of the task controlled by c to d from now.
+// See SetDeadline.
+func (c Controller) Renew(d time.Duration) (time.Time, error) {
+	return c.SetDeadline(time.Now().Add(d))
+}
+
+// parentDeadline returns the deadline inherited from the ancestors of ks.
+func (ks *killswitch) parentDeadline() (time.Time, bool) {
+	if ks.parent == nil {
+		return time.Time{}, false
+	}
+	return Controller{kill: ks.parent}.Deadline()
+}
+
+// lapse is called when the deadline of a killswitch is found to have passed,
+// by its timer or by Checkpoint.
+// The killswitch is triggered unless the deadline was extended in the
+// meantime. The decision is made once, under ks.mu, so that LeaseLapsed is
+// emitted exactly once, and before the cancellation can be observed.
+func (ks *killswitch) lapse() {
+	ks.mu.Lock()
+	deadline := ks.deadline
+	if ks.err != nil || ks.lapsed || ks.frozen || deadline.IsZero() || time.Now().Before(deadline) {
+		ks.mu.Unlock()
+		return
+	}
+	ks.lapsed = true
+	ks.mu.Unlock()
+
+	ks.emit(Event{Kind: LeaseLapsed, Deadline: deadline, Err: ErrTimedOut})
+	ks.trigger(ErrTimedOut, true)
+}
@@ -0,0 +1,96 @@
+package execution
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetDeadline(t *testing.T) {
+	c := NewController()
+	child := c.Spawn()
+
+	var mu sync.Mutex
+	var events []EventKind
+	child.Observe(func(e Event) {
+		mu.Lock()
+		events = append(events, e.Kind)
+		mu.Unlock()
+	})
+
+	if _, err := child.Renew(50 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		child.Renew(50 * time.Millisecond)
+	}
+	if err := child.Checkpoint(); err != nil {
+		t.Errorf("A renewed lease should not lapse. Got %v", err)
+	}
+
+	select {
+	case <-child.WasCancelled(nil):
+	case <-time.After(time.Second):
+		t.Fatal("The lease should have lapsed.")
+	}
+	if err := child.Checkpoint(); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	if _, err := child.Renew(time.Second); err != ErrTimedOut {
+		t.Errorf("A lapsed lease cannot be renewed. Got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 7 || events[0] != LeaseRenewed || events[6] != LeaseLapsed {
+		t.Errorf("Unexpected events: %v", events)
+	}
+}
+
+func TestSetDeadlineCap(t *testing.T) {
+	limit := Timeout(time.Hour)
+	parent := NewController().CancelAfter(limit)
+	child := parent.Spawn()
+
+	d, err := child.SetDeadline(limit.Add(time.Hour))
+	if err != nil || !d.Equal(limit) {
+		t.Errorf("The deadline should be capped at %v but got %v, %v", limit, d, err)
+	}
+
+	shorter := Timeout(time.Minute)
+	if d, _ := child.SetDeadline(shorter); !d.Equal(shorter) {
+		t.Errorf("Expected %v but got %v", shorter, d)
+	}
+	if d, _ := child.SetDeadline(time.Time{}); !d.Equal(limit) {
+		t.Errorf("Removing the deadline should leave the cap %v but got %v", limit, d)
+	}
+}
+
+func TestLapseOnce(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		c := NewController().Spawn()
+		var lapsed atomic.Int32
+		c.Observe(func(e Event) {
+			if e.Kind == LeaseLapsed {
+				lapsed.Add(1)
+			}
+		})
+		c.Renew(2 * time.Millisecond)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c.Checkpoint() == nil {
+				}
+			}()
+		}
+		wg.Wait()
+		if n := lapsed.Load(); n != 1 {
+			t.Fatalf("LeaseLapsed should be emitted exactly once, before the cancellation is observed. Got %v", n)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package execution
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrExceedsBurst is returned by Limiter.WaitN when more tokens are requested
+// than the bucket can ever hold.
+var ErrExceedsBurst = errors.New("Request exceeds the limiter's burst!")
+
+// A Limiter is a token-bucket rate limiter: the bucket holds up to burst tokens
+// and is refilled at rate tokens per second.
+// A Limiter is safe for concurrent use.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing rate events per second, with bursts
+// of at most burst events. The bucket is initially full. With a rate that is
+// not positive, the bucket is never refilled.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// advance refills the bucket up to now. l.mu must be held.
+func (l *Limiter) advance(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+	}
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+}
+
+// Allow reports whether an event may happen now, consuming a token if so.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until an event may happen. See WaitN.
+func (l *Limiter) Wait(c Controller) error {
+	return l.WaitN(c, 1)
+}
+
+// WaitN blocks until n events may happen, on behalf of the task controlled
+// by c.
+//
+// It returns the cancellation cause as soon as c is cancelled.
+// If the wait would extend past the deadline of c, it does not wait at all
+// and returns ErrTimedOut right away.
+// In both cases, the tokens reserved for the waiter are given back.
+func (l *Limiter) WaitN(c Controller, n int) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	if n > l.burst {
+		return ErrExceedsBurst
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.advance(now)
+	l.tokens -= float64(n)
+	if l.tokens < 0 && l.rate <= 0 {
+		// The bucket is never refilled: this request can never be satisfied.
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		if _, ok := c.Deadline(); ok {
+			return ErrTimedOut
+		}
+		<-c.sigKill
+		return c.kill.cause()
+	}
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if deadline, ok := c.Deadline(); ok && now.Add(delay).After(deadline) {
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ErrTimedOut
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := c.Sleep(delay); err != nil {
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
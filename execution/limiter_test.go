@@ -0,0 +1,84 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(100, 2)
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("The bucket should initially be full.")
+	}
+	if l.Allow() {
+		t.Fatal("The bucket should be empty.")
+	}
+
+	start := time.Now()
+	if err := l.Wait(NewController()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 5*time.Millisecond {
+		t.Error("Wait should have waited for a token.")
+	}
+
+	if err := l.WaitN(NewController(), 3); err != ErrExceedsBurst {
+		t.Errorf("Expected: %v but got: %v", ErrExceedsBurst, err)
+	}
+}
+
+func TestLimiterDeadline(t *testing.T) {
+	l := NewLimiter(1, 1)
+	l.Allow()
+
+	c := NewController().CancelAfter(Timeout(10 * time.Millisecond))
+	start := time.Now()
+	if err := l.Wait(c); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	if time.Since(start) > 5*time.Millisecond {
+		t.Error("Wait should not have waited since the deadline is too short.")
+	}
+
+	parent := NewController()
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		parent.Cancel()
+	}()
+	if err := l.Wait(parent.Spawn()); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+
+	// The reservations were given back.
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.01 {
+		t.Errorf("The reserved tokens should have been given back. Got %v tokens", tokens)
+	}
+}
+
+func TestLimiterZeroRate(t *testing.T) {
+	l := NewLimiter(0, 1)
+	if err := l.Wait(NewController()); err != nil {
+		t.Fatalf("The initial token should be available. Got %v", err)
+	}
+	c := NewController().CancelAfter(Timeout(time.Hour))
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(c); err != ErrTimedOut {
+			t.Fatalf("A bucket that is never refilled should not let events through. Got %v", err)
+		}
+	}
+
+	c = NewController()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.Cancel()
+	}()
+	if err := l.Wait(c); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	if l.Allow() {
+		t.Error("The bucket should still be empty.")
+	}
+}
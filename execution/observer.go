@@ -0,0 +1,89 @@
+package execution
+
+import (
+	"time"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+const (
+	// LeaseRenewed is emitted when the deadline of a task is changed via
+	// SetDeadline or Renew.
+	LeaseRenewed EventKind = iota
+	// LeaseLapsed is emitted when a task is cancelled because its deadline
+	// passed.
+	LeaseLapsed
+	// BreakerOpened is emitted when a Breaker starts failing fast.
+	BreakerOpened
+	// BreakerHalfOpened is emitted when a Breaker lets a probe through.
+	BreakerHalfOpened
+	// BreakerClosed is emitted when a Breaker lets every call through again.
+	BreakerClosed
+	// ChildFailed is emitted when a child of a Supervisor returns an error or
+	// panics.
+	ChildFailed
+	// ChildRestarted is emitted when a Supervisor restarts a child.
+	ChildRestarted
+	// SupervisorGaveUp is emitted when a Supervisor exceeds its restart
+	// intensity and stops.
+	SupervisorGaveUp
+)
+
+// An Event describes a change in the lifecycle of a task.
+type Event struct {
+	Kind EventKind
+	// At is the time at which the event occurred.
+	At time.Time
+	// Deadline is the deadline of the task when the event occurred, if any.
+	Deadline time.Time
+	// Err is the error associated with the event, if any.
+	Err error
+	// Name identifies the child of a Supervisor the event relates to, if any.
+	Name string
+}
+
+// An Observer is a function that is notified of the events of a task.
+// It is called synchronously and should therefore return quickly.
+type Observer func(Event)
+
+// Observe registers o to be notified of the events of the task controlled by
+// c. The returned stop function unregisters o. It returns false if o was
+// already unregistered.
+func (c Controller) Observe(o Observer) (stop func() bool) {
+	ks := c.kill
+	key := &o
+	ks.mu.Lock()
+	if ks.observers == nil {
+		ks.observers = make(map[*Observer]struct{})
+	}
+	ks.observers[key] = struct{}{}
+	ks.mu.Unlock()
+
+	return func() bool {
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		if _, ok := ks.observers[key]; !ok {
+			return false
+		}
+		delete(ks.observers, key)
+		return true
+	}
+}
+
+// emit notifies the observers of a killswitch of e.
+func (ks *killswitch) emit(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	ks.mu.Lock()
+	observers := make([]Observer, 0, len(ks.observers))
+	for o := range ks.observers {
+		observers = append(observers, *o)
+	}
+	ks.mu.Unlock()
+
+	for _, o := range observers {
+		o(e)
+	}
+}
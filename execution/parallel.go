@@ -0,0 +1,226 @@
+package execution
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrorMode specifies how the parallel helpers handle the failure of an item.
+type ErrorMode int
+
+const (
+	// FailFast stops at the first error: the remaining items are cancelled and
+	// the first error is returned.
+	FailFast ErrorMode = iota
+	// CollectErrors processes every item and returns all the errors, joined in
+	// input order.
+	CollectErrors
+)
+
+// ParallelOptions configures the parallel helpers.
+// The zero value processes GOMAXPROCS items at a time, without per-item
+// deadline, in FailFast mode.
+type ParallelOptions struct {
+	// Workers is the maximum number of items processed at the same time.
+	Workers int
+	// ItemTimeout limits the processing time of each item. Zero means no limit
+	// other than the deadline of the parent Controller.
+	ItemTimeout time.Duration
+	// Mode specifies how errors are handled.
+	Mode ErrorMode
+}
+
+func (o ParallelOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// item spawns the Controller under which an item is processed.
+func (o ParallelOptions) item(g Controller) Controller {
+	if o.ItemTimeout > 0 {
+		return g.spawnUntil(Timeout(o.ItemTimeout))
+	}
+	return g.Spawn()
+}
+
+// parallel calls f for every index in [0, n), under a child Controller per
+// index, with bounded parallelism.
+func parallel(c Controller, opts ParallelOptions, n int, f func(Controller, int) error) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	g := c.Spawn()
+	defer g.Cancel()
+
+	slots := make(chan struct{}, opts.workers())
+	errs := make([]error, n)
+	var first error
+	var once sync.Once
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		if Send(g, slots, struct{}{}) != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			item := opts.item(g)
+			if err := item.Finish(f(item, i)); err != nil {
+				errs[i] = err
+				if opts.Mode == FailFast {
+					once.Do(func() {
+						first = err
+						g.Cancel()
+					})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cause := c.Checkpoint(); cause != nil {
+		return cause
+	}
+	if opts.Mode == FailFast {
+		return first
+	}
+	return errors.Join(errs...)
+}
+
+// ParallelMap applies f to every element of in, in parallel, each under its
+// own child of c, and returns the results in input order.
+//
+// If c is cancelled, the remaining items are cancelled and the cancellation
+// cause is returned. In CollectErrors mode, the results of the items that
+// failed are zero values.
+func ParallelMap[T, R any](c Controller, opts ParallelOptions, in []T, f func(Controller, T) (R, error)) ([]R, error) {
+	out := make([]R, len(in))
+	err := parallel(c, opts, len(in), func(item Controller, i int) error {
+		r, err := f(item, in[i])
+		out[i] = r
+		return err
+	})
+	return out, err
+}
+
+// ParallelForEach calls f for every element of in, in parallel, each under
+// its own child of c. See ParallelMap.
+func ParallelForEach[T any](c Controller, opts ParallelOptions, in []T, f func(Controller, T) error) error {
+	return parallel(c, opts, len(in), func(item Controller, i int) error {
+		return f(item, in[i])
+	})
+}
+
+// ParallelReduce maps every element of in with f, in parallel as ParallelMap
+// does, then folds the results in input order, starting from init.
+// In CollectErrors mode, the results of the items that failed are skipped.
+func ParallelReduce[T, R, A any](c Controller, opts ParallelOptions, in []T, init A, f func(Controller, T) (R, error), fold func(A, R) A) (A, error) {
+	out := make([]R, len(in))
+	failed := make([]bool, len(in))
+	err := parallel(c, opts, len(in), func(item Controller, i int) error {
+		r, err := f(item, in[i])
+		out[i], failed[i] = r, err != nil
+		return err
+	})
+	if err != nil && opts.Mode == FailFast {
+		return init, err
+	}
+	acc := init
+	for i, r := range out {
+		if !failed[i] {
+			acc = fold(acc, r)
+		}
+	}
+	return acc, err
+}
+
+// ParallelMapChan is the streaming counterpart of ParallelMap: it applies f to
+// the values received from in until in is closed, and sends the results on the
+// returned channel, in input order. In CollectErrors mode, the results of the
+// items that failed are skipped.
+//
+// The out channel is closed once every result has been sent or the processing
+// has stopped. wait blocks until then and returns the error, as ParallelMap
+// would.
+func ParallelMapChan[T, R any](c Controller, opts ParallelOptions, in <-chan T, f func(Controller, T) (R, error)) (out <-chan R, wait func() error) {
+	type result struct {
+		r   R
+		err error
+	}
+	g := c.Spawn()
+	results := make(chan R)
+	slots := make(chan struct{}, opts.workers())
+	pending := make(chan chan result, opts.workers())
+	done := newsignalchan()
+	var errs []error
+
+	// Dispatch the items, in order. The number of items being processed is
+	// bounded by slots, independently of how many results wait in pending.
+	go func() {
+		defer close(pending)
+		for {
+			v, ok, err := Recv(g, in)
+			if err != nil || !ok {
+				return
+			}
+			if Send(g, slots, struct{}{}) != nil {
+				return
+			}
+			res := make(chan result, 1)
+			if Send(g, pending, res) != nil {
+				<-slots
+				return
+			}
+			go func(v T) {
+				defer func() { <-slots }()
+				item := opts.item(g)
+				r, err := f(item, v)
+				res <- result{r, item.Finish(err)}
+			}(v)
+		}
+	}()
+
+	// Collect the results, in order.
+	go func() {
+		defer close(done)
+		defer close(results)
+		defer g.Cancel()
+		for res := range pending {
+			r := <-res
+			if r.err != nil {
+				errs = append(errs, r.err)
+				if opts.Mode == FailFast {
+					g.Cancel()
+					break
+				}
+				continue
+			}
+			if Send(g, results, r.r) != nil {
+				break
+			}
+		}
+		// Wait for the items still in flight.
+		for res := range pending {
+			<-res
+		}
+	}()
+
+	return results, func() error {
+		<-done
+		if cause := c.Checkpoint(); cause != nil {
+			return cause
+		}
+		if opts.Mode == FailFast && len(errs) > 0 {
+			return errs[0]
+		}
+		return errors.Join(errs...)
+	}
+}
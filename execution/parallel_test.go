@@ -0,0 +1,151 @@
+package execution
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMap(t *testing.T) {
+	var running, peak atomic.Int32
+	in := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	out, err := ParallelMap(NewController(), ParallelOptions{Workers: 3}, in, func(c Controller, v int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(8-v) * time.Millisecond)
+		return v * v, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range in {
+		if out[i] != v*v {
+			t.Fatalf("The results should be in input order. Got %v", out)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Errorf("At most 3 items should be processed at a time. Got %v", peak.Load())
+	}
+}
+
+func TestParallelErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	in := []int{1, 2, 3, 4}
+	f := func(c Controller, v int) error {
+		if v%2 == 1 {
+			return errOdd
+		}
+		return c.Sleep(50 * time.Millisecond)
+	}
+
+	var cancelled atomic.Int32
+	err := ParallelForEach(NewController(), ParallelOptions{Workers: 4}, in, func(c Controller, v int) error {
+		err := f(c, v)
+		if err == ErrCancelled {
+			cancelled.Add(1)
+		}
+		return err
+	})
+	if err != errOdd {
+		t.Errorf("Expected: %v but got: %v", errOdd, err)
+	}
+	if cancelled.Load() == 0 {
+		t.Error("The remaining items should have been cancelled.")
+	}
+
+	err = ParallelForEach(NewController(), ParallelOptions{Mode: CollectErrors}, in, f)
+	if !errors.Is(err, errOdd) || len(err.(interface{ Unwrap() []error }).Unwrap()) != 2 {
+		t.Errorf("Expected 2 collected errors but got: %v", err)
+	}
+
+	err = ParallelForEach(NewController(), ParallelOptions{ItemTimeout: time.Millisecond}, []int{2}, f)
+	if err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+
+	parent := NewController()
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		parent.Cancel()
+	}()
+	err = ParallelForEach(parent.Spawn(), ParallelOptions{}, []int{2, 4}, f)
+	if err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestParallelReduce(t *testing.T) {
+	sum, err := ParallelReduce(NewController(), ParallelOptions{Mode: CollectErrors}, []int{1, 2, 3, 4}, "",
+		func(c Controller, v int) (int, error) {
+			if v == 3 {
+				return 0, errors.New("three")
+			}
+			return v, nil
+		},
+		func(acc string, v int) string { return acc + string(rune('0'+v)) })
+	if sum != "124" || err == nil {
+		t.Errorf("Expected the ordered fold of the successful items and an error. Got %q, %v", sum, err)
+	}
+}
+
+func TestParallelMapChan(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	var running, peak atomic.Int32
+	out, wait := ParallelMapChan(NewController(), ParallelOptions{Workers: 2}, in, func(c Controller, v int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(10-v) * time.Millisecond / 2)
+		return v * 2, nil
+	})
+	i := 0
+	for v := range out {
+		if v != i*2 {
+			t.Errorf("Expected %v but got %v", i*2, v)
+		}
+		i++
+	}
+	if err := wait(); err != nil || i != 10 {
+		t.Errorf("Expected 10 results and no error but got %v and %v", i, err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("At most 2 items should be processed at a time. Got %v", peak.Load())
+	}
+
+	errFailed := errors.New("failed")
+	in = make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			in <- i
+		}
+	}()
+	out, wait = ParallelMapChan(NewController(), ParallelOptions{}, in, func(c Controller, v int) (int, error) {
+		if v == 3 {
+			return 0, errFailed
+		}
+		return v, nil
+	})
+	for range out {
+	}
+	if err := wait(); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+}
@@ -0,0 +1,166 @@
+package execution
+
+import (
+	"time"
+)
+
+// Pause suspends the hierarchy of subtasks running in child goroutines until
+// Resume is called.
+//
+// A paused subtask is not interrupted: it blocks at its next call to
+// WaitIfPaused. The pause applies to every descendant of c, including the
+// ones spawned afterwards, and to c itself, as for Cancel.
+// Time keeps running while paused: the deadlines may expire. See Suspend.
+func (c Controller) Pause() {
+	c.kill.pause(false)
+}
+
+// Suspend is similar to Pause, except that the time spent paused is excluded
+// from the deadlines set on c and on its descendants (via CancelAfter).
+// The deadlines of the ancestors of c keep on running.
+func (c Controller) Suspend() {
+	c.kill.pause(true)
+}
+
+// Resume ends a pause started by Pause or Suspend.
+// The deadlines that were suspended are pushed back by the time spent paused.
+func (c Controller) Resume() {
+	ks := c.kill
+	ks.mu.Lock()
+	if ks.sigResume == nil {
+		ks.mu.Unlock()
+		return
+	}
+	close(ks.sigResume)
+	ks.sigResume = nil
+	ks.mu.Unlock()
+	ks.thaw()
+}
+
+// IsPaused reports whether the task is paused, either directly or because one
+// of its ancestors is.
+func (c Controller) IsPaused() bool {
+	return c.kill.pausedOn() != nil
+}
+
+// WaitIfPaused is a checkpoint that blocks as long as the task is paused.
+// It returns nil once the task may keep on running, or the cancellation cause
+// if the task was cancelled, possibly while it was paused.
+func (c Controller) WaitIfPaused() error {
+	for {
+		if err := c.Checkpoint(); err != nil {
+			return err
+		}
+		resume := c.kill.pausedOn()
+		if resume == nil {
+			return nil
+		}
+		select {
+		case <-resume:
+		case <-c.sigKill:
+			return c.kill.cause()
+		}
+	}
+}
+
+func (ks *killswitch) pause(freeze bool) {
+	ks.mu.Lock()
+	if ks.sigResume != nil || ks.err != nil {
+		ks.mu.Unlock()
+		return
+	}
+	ks.sigResume = newsignalchan()
+	ks.mu.Unlock()
+	ks.freeze(freeze)
+}
+
+// pausedOn returns the resume channel of the closest paused killswitch in the
+// chain going from ks to the root, or nil if none is paused.
+func (ks *killswitch) pausedOn() chan struct{} {
+	for ; ks != nil; ks = ks.parent {
+		ks.mu.Lock()
+		resume := ks.sigResume
+		ks.mu.Unlock()
+		if resume != nil {
+			return resume
+		}
+	}
+	return nil
+}
+
+// freeze stops the watchdog timers of a killswitch and of its descendants,
+// since a paused task cannot report any progress.
+// If deadlines is true, their deadline timers are stopped as well, and the
+// deadlines set until thaw is called are not started: this also applies to
+// the descendants spawned in the meantime.
+func (ks *killswitch) freeze(deadlines bool) {
+	ks.mu.Lock()
+	if deadlines {
+		ks.suspended = true
+	}
+	if deadlines && ks.timer != nil && !ks.frozen && ks.timer.Stop() {
+		ks.frozen = true
+		ks.remaining = time.Until(ks.deadline)
+		ks.expiry.Store(0)
+	}
+	if ks.wdTimer != nil {
+		ks.wdTimer.Stop()
+	}
+	ks.wdStopped = true
+	children := ks.live()
+	ks.mu.Unlock()
+
+	for _, child := range children {
+		child.freeze(deadlines)
+	}
+}
+
+// thaw restarts the timers stopped by freeze, pushing the deadlines back by
+// the time spent frozen.
+// The descendants that are paused on their own are left untouched.
+func (ks *killswitch) thaw() {
+	ks.mu.Lock()
+	if ks.err != nil {
+		ks.mu.Unlock()
+		return
+	}
+	ks.suspended = false
+	if ks.frozen {
+		ks.setDeadline(time.Now().Add(ks.remaining))
+		ks.schedule(ks.remaining)
+	}
+	ks.wdStopped = false
+	ks.resetWatchdog()
+	children := ks.live()
+	ks.mu.Unlock()
+
+	for _, child := range children {
+		child.mu.Lock()
+		paused := child.sigResume != nil
+		child.mu.Unlock()
+		if !paused {
+			child.thaw()
+		}
+	}
+}
+
+// schedule starts the deadline timer of a killswitch so that it fires in d.
+// If the killswitch is suspended, the timer is only started by thaw.
+// ks.mu must be held.
+func (ks *killswitch) schedule(d time.Duration) {
+	if ks.suspended {
+		if ks.timer != nil {
+			ks.timer.Stop()
+		}
+		ks.frozen = true
+		ks.remaining = d
+		ks.expiry.Store(0)
+		return
+	}
+	ks.frozen = false
+	if ks.timer == nil {
+		ks.timer = time.AfterFunc(d, ks.lapse)
+		return
+	}
+	ks.timer.Reset(d)
+}
@@ -0,0 +1,98 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	c := NewController()
+	child := c.Spawn()
+
+	if err := child.WaitIfPaused(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	c.Pause()
+	if !child.IsPaused() || !child.Spawn().IsPaused() {
+		t.Error("The pause should apply to all descendants.")
+	}
+
+	resumed := make(chan error)
+	go func() { resumed <- child.WaitIfPaused() }()
+
+	select {
+	case <-resumed:
+		t.Error("WaitIfPaused should block while paused.")
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	c.Resume()
+	select {
+	case err := <-resumed:
+		if err != nil {
+			t.Errorf("Expected no error but got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("WaitIfPaused should have returned once resumed.")
+	}
+
+	c.Pause()
+	go func() { resumed <- child.WaitIfPaused() }()
+	c.Cancel()
+	if err := <-resumed; err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	c := NewController()
+	child := c.Spawn().CancelAfter(Timeout(20 * time.Millisecond))
+	before, _ := child.Deadline()
+
+	c.Suspend()
+	time.Sleep(30 * time.Millisecond)
+	if err := child.Checkpoint(); err != nil {
+		t.Errorf("The deadline should not expire while suspended. Got %v", err)
+	}
+
+	c.Resume()
+	after, _ := child.Deadline()
+	if !after.After(before) {
+		t.Errorf("The deadline should have been pushed back: %v is not after %v", after, before)
+	}
+	select {
+	case <-child.WasCancelled(nil):
+		if err := child.Checkpoint(); err != ErrTimedOut {
+			t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Error("The deadline should have expired after resuming.")
+	}
+}
+
+func TestSuspendLateDeadlines(t *testing.T) {
+	c := NewController()
+	c.Suspend()
+	spawned := c.Spawn().CancelAfter(Timeout(10 * time.Millisecond))
+	renewed := c.Spawn()
+	if _, err := renewed.Renew(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	for _, child := range []Controller{spawned, renewed} {
+		if err := child.Checkpoint(); err != nil {
+			t.Errorf("A deadline set while suspended should not expire. Got %v", err)
+		}
+	}
+
+	c.Resume()
+	for _, child := range []Controller{spawned, renewed} {
+		select {
+		case <-child.WasCancelled(nil):
+		case <-time.After(200 * time.Millisecond):
+			t.Error("The deadline should have expired after resuming.")
+		}
+	}
+}
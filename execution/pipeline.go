@@ -0,0 +1,148 @@
+package execution
+
+import (
+	"sync"
+)
+
+// Pipeline connects stages through bounded channels. Each stage runs in its
+// own goroutine, under a child of the pipeline's Controller.
+//
+// A failure in any stage cancels every other stage, upstream and downstream.
+// When a stage stops, it closes its output channel and drains its input so
+// that no goroutine stays blocked: Wait returns only once every stage has
+// exited.
+//
+//	p := NewPipeline(c, 16)
+//	lines := Source(p, readLines)
+//	records := Stage(p, lines, 4, parse)
+//	Sink(p, records, store)
+//	err := p.Wait()
+type Pipeline struct {
+	c      Controller
+	g      Controller
+	buffer int
+
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+// NewPipeline returns an empty Pipeline whose stages are controlled by c and
+// connected by channels of capacity buffer.
+func NewPipeline(c Controller, buffer int) *Pipeline {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &Pipeline{
+		c:      c,
+		g:      c.Spawn(),
+		buffer: buffer,
+	}
+}
+
+// fail records the first error of the pipeline and cancels every stage.
+func (p *Pipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.g.Cancel()
+	})
+}
+
+// run starts a stage goroutine. Once the stage has returned, and any failure
+// has been propagated, teardown is called.
+func (p *Pipeline) run(stage func(Controller) error, teardown func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer teardown()
+		s := p.g.Spawn()
+		if err := s.Finish(stage(s)); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until every stage has exited. It returns the first error
+// encountered by a stage, or the cancellation cause of the pipeline's
+// Controller if it was cancelled.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	defer p.g.Cancel()
+	if cause := p.c.Checkpoint(); cause != nil {
+		return cause
+	}
+	return p.err
+}
+
+// Source adds a stage that produces values by sending them on out, typically
+// with Send so that it stops when the pipeline is cancelled.
+// The returned channel is closed once f returns.
+func Source[T any](p *Pipeline, f func(c Controller, out chan<- T) error) <-chan T {
+	out := make(chan T, p.buffer)
+	p.run(func(s Controller) error {
+		return f(s, out)
+	}, func() { close(out) })
+	return out
+}
+
+// Stage adds a stage that applies f to the values received from in and sends
+// the results downstream. Up to workers values are processed at the same
+// time; with more than one worker, the output order is not preserved.
+// The returned channel is closed once in is closed or the pipeline stops.
+func Stage[T, R any](p *Pipeline, in <-chan T, workers int, f func(Controller, T) (R, error)) <-chan R {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan R, p.buffer)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		p.run(func(s Controller) error {
+			for {
+				v, ok, err := Recv(s, in)
+				if err != nil || !ok {
+					return err
+				}
+				r, err := f(s, v)
+				if err != nil {
+					return err
+				}
+				if err := Send(s, out, r); err != nil {
+					return err
+				}
+			}
+		}, func() {
+			drain(in)
+			wg.Done()
+		})
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Sink adds a final stage that calls f for every value received from in.
+func Sink[T any](p *Pipeline, in <-chan T, f func(Controller, T) error) {
+	p.run(func(s Controller) error {
+		for {
+			v, ok, err := Recv(s, in)
+			if err != nil || !ok {
+				return err
+			}
+			if err := f(s, v); err != nil {
+				return err
+			}
+		}
+	}, func() { drain(in) })
+}
+
+// drain discards the values left in ch until it is closed, so that the
+// upstream stage is never blocked on a send.
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
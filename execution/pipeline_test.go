@@ -0,0 +1,95 @@
+package execution
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func count(n int) func(Controller, chan<- int) error {
+	return func(c Controller, out chan<- int) error {
+		for i := 0; i < n; i++ {
+			if err := Send(c, out, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(NewController(), 2)
+	nums := Source(p, count(100))
+	strs := Stage(p, nums, 3, func(c Controller, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	})
+	var n int
+	Sink(p, strs, func(c Controller, s string) error {
+		n++
+		return nil
+	})
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 100 {
+		t.Errorf("Expected 100 values but got %v", n)
+	}
+}
+
+func TestPipelineFailure(t *testing.T) {
+	before := runtime.NumGoroutine()
+	errFailed := errors.New("failed")
+
+	p := NewPipeline(NewController(), 1)
+	nums := Source(p, count(1<<30))
+	doubled := Stage(p, nums, 2, func(c Controller, v int) (int, error) {
+		if v == 10 {
+			return 0, errFailed
+		}
+		return 2 * v, nil
+	})
+	Sink(p, doubled, func(c Controller, v int) error {
+		return c.Sleep(time.Millisecond)
+	})
+	if err := p.Wait(); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+
+	// A source that ignores cancellation must not leak either: the next
+	// stage drains its output.
+	p = NewPipeline(NewController(), 0)
+	raw := Source(p, func(c Controller, out chan<- int) error {
+		for i := 0; i < 50; i++ {
+			out <- i
+		}
+		return nil
+	})
+	Sink(p, raw, func(c Controller, v int) error {
+		return errFailed
+	})
+	if err := p.Wait(); err != errFailed {
+		t.Errorf("Expected: %v but got: %v", errFailed, err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Goroutines leaked: %v before, %v after", before, after)
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	c := NewController()
+	p := NewPipeline(c, 4)
+	Sink(p, Source(p, count(1<<30)), func(c Controller, v int) error {
+		return nil
+	})
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		c.Cancel()
+	}()
+	if err := p.Wait(); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
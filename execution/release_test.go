@@ -0,0 +1,41 @@
+//go:build go1.24
+
+package execution
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSpawnRelease(t *testing.T) {
+	root := NewController()
+	for i := 0; i < 1000; i++ {
+		root.Spawn().Finish(nil)
+		root.Spawn().Spawn().CancelAfter(Timeout(time.Hour)).Finish(nil)
+		c := root.Spawn()
+		c.Checkpoint()
+	}
+	for i := 0; i < 100 && attached(root) > 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := attached(root); n != 0 {
+		t.Errorf("Completed subtasks should not stay attached to their parent. Got %v", n)
+	}
+
+	// Subtasks that may still be observed must not be released.
+	done := root.Spawn().WasCancelled(nil)
+	grandchild := root.Spawn().Spawn()
+	runtime.GC()
+	runtime.GC()
+	root.Cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("A subtask whose channel is observed should still be cancelled.")
+	}
+	if err := grandchild.Checkpoint(); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
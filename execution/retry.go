@@ -0,0 +1,119 @@
+package execution
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy specifies how an operation is retried by Retry.
+// The zero value retries any error, with no delay, until the Controller is
+// cancelled.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts. Zero means no limit.
+	Attempts int
+	// AttemptTimeout limits the duration of each attempt. Zero means no limit
+	// other than the deadline of the Controller.
+	AttemptTimeout time.Duration
+	// MinAttemptTime is the minimum remaining time needed to start an attempt.
+	MinAttemptTime time.Duration
+
+	// InitialBackoff is the delay before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows after each attempt.
+	// Values lower than 1 are treated as 1.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized.
+	Jitter float64
+
+	// Retryable reports whether an attempt that failed with err should be
+	// followed by another one. If nil, every error is retryable.
+	Retryable func(err error) bool
+}
+
+// backoff returns the delay before the attempt that follows attempt n.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < n; i++ {
+		if p.Multiplier > 1 {
+			d *= p.Multiplier
+		}
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// Retry runs op until it succeeds, with exponential backoff and jitter between
+// the attempts, as specified by p.
+// Each attempt runs under its own child of c, finished once the attempt
+// returns (see Finish).
+//
+// Retry stops as soon as c is cancelled, returning the cancellation cause.
+// It does not start an attempt, nor sleep before one, if there is not enough
+// time left before the deadline of c: it fails fast with ErrTimedOut, joined
+// with the error of the last attempt.
+// Otherwise, it returns the error of the last attempt.
+func Retry(c Controller, p RetryPolicy, op func(Controller) error) error {
+	var err error
+	for n := 1; ; n++ {
+		if cause := c.Checkpoint(); cause != nil {
+			return cause
+		}
+		if !enoughTime(c, p.MinAttemptTime) {
+			return timedOut(err)
+		}
+
+		var attempt Controller
+		if p.AttemptTimeout > 0 {
+			attempt = c.spawnUntil(Timeout(p.AttemptTimeout))
+		} else {
+			attempt = c.Spawn()
+		}
+		err = attempt.Finish(op(attempt))
+		if err == nil {
+			return nil
+		}
+
+		if cause := c.Checkpoint(); cause != nil {
+			return cause
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+		if p.Attempts > 0 && n >= p.Attempts {
+			return err
+		}
+
+		backoff := p.backoff(n)
+		if !enoughTime(c, backoff+p.MinAttemptTime) {
+			return timedOut(err)
+		}
+		if cause := c.Sleep(backoff); cause != nil {
+			return cause
+		}
+	}
+}
+
+// timedOut returns ErrTimedOut joined with the error of the last attempt, if
+// any.
+func timedOut(err error) error {
+	if err == nil {
+		return ErrTimedOut
+	}
+	return errors.Join(ErrTimedOut, err)
+}
+
+// enoughTime reports whether there is at least d left before the deadline of c.
+func enoughTime(c Controller, d time.Duration) bool {
+	deadline, ok := c.Deadline()
+	return !ok || time.Until(deadline) >= d
+}
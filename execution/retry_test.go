@@ -0,0 +1,90 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	calls := 0
+	err := Retry(NewController(), RetryPolicy{InitialBackoff: time.Millisecond, Multiplier: 2, Jitter: 0.5}, func(c Controller) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success after 3 calls but got %v after %v calls", err, calls)
+	}
+
+	calls = 0
+	err = Retry(NewController(), RetryPolicy{Attempts: 2}, func(c Controller) error {
+		calls++
+		return errTransient
+	})
+	if err != errTransient || calls != 2 {
+		t.Errorf("Expected %v after 2 calls but got %v after %v calls", errTransient, err, calls)
+	}
+
+	errFatal := errors.New("fatal")
+	calls = 0
+	err = Retry(NewController(), RetryPolicy{Retryable: func(err error) bool { return err != errFatal }}, func(c Controller) error {
+		calls++
+		return errFatal
+	})
+	if err != errFatal || calls != 1 {
+		t.Errorf("Expected %v after 1 call but got %v after %v calls", errFatal, err, calls)
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	calls := 0
+	err := Retry(NewController(), RetryPolicy{Attempts: 3, AttemptTimeout: 2 * time.Millisecond}, func(c Controller) error {
+		calls++
+		if calls < 3 {
+			return c.Sleep(time.Second)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Timed out attempts should be retried. Got %v after %v calls", err, calls)
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	errTransient := errors.New("transient")
+	c := NewController().CancelAfter(Timeout(50 * time.Millisecond))
+	start := time.Now()
+	err := Retry(c, RetryPolicy{InitialBackoff: time.Second}, func(c Controller) error {
+		return errTransient
+	})
+	if !errors.Is(err, ErrTimedOut) || !errors.Is(err, errTransient) {
+		t.Errorf("Expected %v joined with %v but got %v", ErrTimedOut, errTransient, err)
+	}
+	if time.Since(start) > 40*time.Millisecond {
+		t.Error("Retry should have failed fast instead of sleeping past the deadline.")
+	}
+
+	short := NewController().CancelAfter(Timeout(50 * time.Millisecond))
+	err = Retry(short, RetryPolicy{MinAttemptTime: time.Second}, func(c Controller) error {
+		return nil
+	})
+	if err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+
+	parent := NewController()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		parent.Cancel()
+	}()
+	err = Retry(parent.Spawn(), RetryPolicy{InitialBackoff: time.Millisecond}, func(c Controller) error {
+		return errTransient
+	})
+	if err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+}
@@ -0,0 +1,116 @@
+package execution
+
+import (
+	"container/list"
+	"sync"
+)
+
+// A Semaphore is a weighted semaphore whose Acquire method can be abandoned
+// when a task is cancelled.
+//
+// Waiters are served in FIFO order: a large request at the front of the queue
+// blocks the smaller ones behind it, which prevents starvation.
+// A Semaphore is safe for concurrent use.
+type Semaphore struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List
+}
+
+type semwaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore returns a Semaphore with a total weight of n.
+func NewSemaphore(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire acquires a weight of n, blocking until it is available or until the
+// task controlled by c is cancelled, in which case the cancellation cause is
+// returned and nothing is acquired.
+func (s *Semaphore) Acquire(c Controller, n int64) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// This request can never be satisfied.
+		s.mu.Unlock()
+		<-c.sigKill
+		return c.kill.cause()
+	}
+	w := semwaiter{n: n, ready: newsignalchan()}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-c.sigKill:
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Acquired after the cancellation: give it back.
+			s.cur -= n
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if !front {
+				s.mu.Unlock()
+				return c.kill.cause()
+			}
+		}
+		s.notify()
+		s.mu.Unlock()
+		return c.kill.cause()
+	}
+}
+
+// TryAcquire acquires a weight of n without blocking.
+// It reports whether it succeeded.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases a weight of n.
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("Semaphore released more than held.")
+	}
+	s.notify()
+}
+
+// notify serves the waiters at the front of the queue. s.mu must be held.
+func (s *Semaphore) notify() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(semwaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
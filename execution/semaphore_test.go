@@ -0,0 +1,67 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore(3)
+	c := NewController()
+
+	if err := s.Acquire(c, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.TryAcquire(2) {
+		t.Fatal("Only a weight of 1 should be available.")
+	}
+
+	acquired := make(chan error)
+	go func() { acquired <- s.Acquire(c, 2) }()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire should block.")
+	case <-time.After(2 * time.Millisecond):
+	}
+	s.Release(2)
+	if err := <-acquired; err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	s.Release(2)
+}
+
+func TestSemaphoreCancellation(t *testing.T) {
+	s := NewSemaphore(2)
+	c := NewController()
+	s.Acquire(c, 2)
+
+	parent := NewController()
+	large := make(chan error)
+	go func() { large <- s.Acquire(parent.Spawn(), 2) }()
+	time.Sleep(2 * time.Millisecond)
+
+	small := make(chan error)
+	go func() { small <- s.Acquire(c, 1) }()
+	time.Sleep(2 * time.Millisecond)
+
+	// Cancelling the waiter at the front of the queue unblocks the next one
+	// once there is room.
+	parent.Cancel()
+	if err := <-large; err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	s.Release(1)
+	select {
+	case err := <-small:
+		if err != nil {
+			t.Errorf("Expected no error but got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("The small request should have been served.")
+	}
+
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if err := s.Acquire(d, 5); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+}
@@ -0,0 +1,41 @@
+package execution
+
+import (
+	"errors"
+	"time"
+)
+
+// Shield runs f, in the current goroutine, under a child of c that ignores the
+// cancellation of c and of its ancestors, including their deadlines.
+// It is meant for critical sections that must not be interrupted, such as
+// committing a transaction or writing the final chunk of a file.
+// The child is otherwise a regular subtask of c: it is notified when c is
+// drained, paused or observed, and it inherits the watchdog interval of c.
+//
+// If c is cancelled while f is running, the cancellation is deferred: Shield
+// returns the cancellation cause as soon as f returns, joined with the error
+// returned by f if any.
+// The Controller passed to f is finished once f returns (see Finish).
+func (c Controller) Shield(f func(Controller) error) error {
+	return c.shield(c.spawnIsolated(), f)
+}
+
+// ShieldUntil is similar to Shield, except that the Controller passed to f is
+// cancelled at t.
+func (c Controller) ShieldUntil(t time.Time, f func(Controller) error) error {
+	s := c.spawnIsolated()
+	s.kill.arm(t)
+	return c.shield(s, f)
+}
+
+func (c Controller) shield(s Controller, f func(Controller) error) error {
+	err := s.Finish(f(s))
+	cause := c.Checkpoint()
+	if cause == nil {
+		return err
+	}
+	if err == nil {
+		return cause
+	}
+	return errors.Join(err, cause)
+}
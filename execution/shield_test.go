@@ -0,0 +1,72 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShield(t *testing.T) {
+	parent := NewController()
+	c := parent.Spawn()
+
+	var inner error
+	err := c.Shield(func(s Controller) error {
+		parent.Cancel()
+		inner = s.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	if inner != nil {
+		t.Errorf("The shielded section should not have been interrupted. Got %v", inner)
+	}
+	if err != ErrCancelled {
+		t.Errorf("Expected the deferred %v but got: %v", ErrCancelled, err)
+	}
+
+	errFailed := errors.New("failed")
+	err = c.Shield(func(s Controller) error { return errFailed })
+	if !errors.Is(err, errFailed) || !errors.Is(err, ErrCancelled) {
+		t.Errorf("Expected both errors to be reported but got: %v", err)
+	}
+
+	if err := NewController().Shield(func(s Controller) error { return nil }); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestShieldUntil(t *testing.T) {
+	c := NewController()
+	err := c.ShieldUntil(Timeout(2*time.Millisecond), func(s Controller) error {
+		return s.Sleep(time.Second)
+	})
+	if err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+}
+
+func TestShieldScope(t *testing.T) {
+	c := NewController().CancelAfter(Timeout(time.Hour))
+	err := c.Shield(func(s Controller) error {
+		if _, ok := s.Deadline(); ok {
+			t.Error("The deadlines of the ancestors should not apply within Shield.")
+		}
+		c.Drain()
+		select {
+		case <-s.Draining():
+		default:
+			t.Error("Draining c should reach the shielded Controller.")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	c.Cancel()
+	err = c.Shield(func(s Controller) error {
+		return s.Checkpoint()
+	})
+	if err != ErrCancelled {
+		t.Errorf("Expected the deferred %v but got: %v", ErrCancelled, err)
+	}
+}
@@ -0,0 +1,107 @@
+package execution
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Exit codes returned by Shutdown.Run.
+const (
+	// ExitOK means that the task tree finished without error.
+	ExitOK = 0
+	// ExitFailure means that the task tree finished with an error.
+	ExitFailure = 1
+	// ExitTimedOut means that the task tree did not finish within the grace
+	// period and was cancelled.
+	ExitTimedOut = 124
+	// ExitCancelled means that the task tree was cancelled by a second signal.
+	ExitCancelled = 130
+)
+
+// Shutdown provides a root Controller for a program, driven by OS signals.
+//
+// Upon the first signal, the program enters its first shutdown phase: the
+// root Controller is drained (see Controller.Drain), meaning that tasks should
+// stop accepting new work and finish the work in progress.
+// Upon a second signal, or once the grace period has elapsed, the root
+// Controller is cancelled, with ErrCancelled or ErrTimedOut respectively.
+type Shutdown struct {
+	Controller
+	grace   time.Duration
+	signals chan os.Signal
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewShutdown returns a Shutdown that listens for the given signals, or for
+// SIGINT and SIGTERM if none are provided.
+// grace is the time left to the task tree to finish once the first signal has
+// been received.
+func NewShutdown(grace time.Duration, sig ...os.Signal) *Shutdown {
+	if len(sig) == 0 {
+		sig = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	s := &Shutdown{
+		Controller: NewController(),
+		grace:      grace,
+		signals:    make(chan os.Signal, 2),
+		done:       newsignalchan(),
+	}
+	signal.Notify(s.signals, sig...)
+	go s.watch()
+	return s
+}
+
+// watch implements the two phases of the shutdown.
+func (s *Shutdown) watch() {
+	select {
+	case <-s.signals:
+	case <-s.done:
+		return
+	case <-s.sigKill:
+		return
+	}
+	s.DrainUntil(Timeout(s.grace))
+
+	select {
+	case <-s.signals:
+		s.cancel(ErrCancelled)
+	case <-s.done:
+	case <-s.sigKill:
+	}
+}
+
+// Stopping returns a channel that is closed when the first shutdown phase
+// begins. It is equivalent to Draining.
+func (s *Shutdown) Stopping() <-chan struct{} {
+	return s.Draining()
+}
+
+// Run runs task under the root Controller and returns, once task has returned,
+// the exit code of the program: ExitOK or ExitFailure if the task tree
+// finished, ExitCancelled or ExitTimedOut if it was cancelled.
+//
+// The Controller is finished afterwards (see Controller.Finish) and the
+// signals are no longer listened for.
+func (s *Shutdown) Run(task func(Controller) error) int {
+	err := task(s.Controller)
+	s.once.Do(func() {
+		close(s.done)
+		signal.Stop(s.signals)
+	})
+
+	code := ExitOK
+	switch s.Checkpoint() {
+	case ErrCancelled:
+		code = ExitCancelled
+	case ErrTimedOut:
+		code = ExitTimedOut
+	}
+	if err = s.Finish(err); err != nil && code == ExitOK {
+		code = ExitFailure
+	}
+	return code
+}
@@ -0,0 +1,52 @@
+package execution
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestShutdownFinished(t *testing.T) {
+	s := NewShutdown(time.Second)
+	code := s.Run(func(c Controller) error {
+		s.signals <- os.Interrupt
+		<-s.Stopping()
+		return c.Checkpoint()
+	})
+	if code != ExitOK {
+		t.Errorf("Expected exit code %v but got %v", ExitOK, code)
+	}
+
+	s = NewShutdown(time.Second)
+	code = s.Run(func(c Controller) error { return errors.New("failure") })
+	if code != ExitFailure {
+		t.Errorf("Expected exit code %v but got %v", ExitFailure, code)
+	}
+}
+
+func TestShutdownTimedOut(t *testing.T) {
+	s := NewShutdown(5 * time.Millisecond)
+	code := s.Run(func(c Controller) error {
+		s.signals <- os.Interrupt
+		<-c.Spawn().WasCancelled(nil)
+		return nil
+	})
+	if code != ExitTimedOut {
+		t.Errorf("Expected exit code %v but got %v", ExitTimedOut, code)
+	}
+}
+
+func TestShutdownEscalation(t *testing.T) {
+	s := NewShutdown(time.Minute)
+	code := s.Run(func(c Controller) error {
+		s.signals <- os.Interrupt
+		<-s.Stopping()
+		s.signals <- os.Interrupt
+		<-c.Spawn().WasCancelled(nil)
+		return c.Checkpoint()
+	})
+	if code != ExitCancelled {
+		t.Errorf("Expected exit code %v but got %v", ExitCancelled, code)
+	}
+}
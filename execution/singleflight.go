@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"sync"
+	"time"
+)
+
+// SingleFlight deduplicates concurrent calls performing the same work,
+// identified by a key: only one of them runs, and its result is shared.
+//
+// The shared work runs under its own root Controller, not under the
+// Controller of the first caller. It is cancelled only once every waiting
+// caller has been cancelled, so that a caller giving up does not fail the
+// others. Its deadline is the latest of the deadlines of the callers waiting
+// for it, and follows them as they come and go.
+//
+// The zero value is ready to use.
+type SingleFlight[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+
+	// scope, if not nil, is the Controller from which the shared work is
+	// spawned, instead of running under a root Controller.
+	scope *Controller
+}
+
+type flight[V any] struct {
+	ctrl    Controller
+	done    chan struct{}
+	v       V
+	err     error
+	dups    int
+	waiters map[*Controller]time.Time
+}
+
+// Do runs fn for key, unless a call for the same key is already in flight, in
+// which case it waits for that call and shares its result.
+// shared reports whether the result was given to several callers.
+//
+// If c is cancelled before the result is available, Do returns the
+// cancellation cause of c, regardless of the outcome of the shared work.
+func (g *SingleFlight[K, V]) Do(c Controller, key K, fn func(Controller) (V, error)) (v V, err error, shared bool) {
+	if err := c.Checkpoint(); err != nil {
+		return v, err, false
+	}
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flight[V])
+	}
+	f, ok := g.calls[key]
+	if ok {
+		f.dups++
+	} else {
+		f = &flight[V]{
+			done:    newsignalchan(),
+			waiters: make(map[*Controller]time.Time),
+		}
+		if g.scope != nil {
+			f.ctrl = g.scope.Spawn()
+		} else {
+			f.ctrl = NewController()
+		}
+		g.calls[key] = f
+	}
+	id := &c
+	f.waiters[id], _ = c.Deadline()
+	f.adjust()
+	g.mu.Unlock()
+	if !ok {
+		// Started once the deadline of the work follows its first caller.
+		go g.run(key, f, fn)
+	}
+
+	select {
+	case <-f.done:
+		g.mu.Lock()
+		shared = f.dups > 0
+		g.mu.Unlock()
+		return f.v, f.err, shared
+	case <-c.sigKill:
+		g.mu.Lock()
+		delete(f.waiters, id)
+		if len(f.waiters) == 0 {
+			f.ctrl.Cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		} else {
+			f.adjust()
+		}
+		g.mu.Unlock()
+		return v, c.kill.cause(), false
+	}
+}
+
+// run performs the shared work.
+func (g *SingleFlight[K, V]) run(key K, f *flight[V], fn func(Controller) (V, error)) {
+	v, err := fn(f.ctrl)
+	err = f.ctrl.Finish(err)
+
+	g.mu.Lock()
+	f.v, f.err = v, err
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// adjust sets the deadline of the shared work to the latest of the deadlines
+// of its waiters. If one of them has no deadline, neither does the work.
+// g.mu must be held.
+func (f *flight[V]) adjust() {
+	var latest time.Time
+	for _, d := range f.waiters {
+		if d.IsZero() {
+			latest = time.Time{}
+			break
+		}
+		if d.After(latest) {
+			latest = d
+		}
+	}
+	f.ctrl.SetDeadline(latest)
+}
@@ -0,0 +1,96 @@
+package execution
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight(t *testing.T) {
+	var g SingleFlight[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(c Controller) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(NewController(), "key", fn)
+			if v != 42 || err != nil || !shared {
+				t.Errorf("Unexpected result: %v, %v, %v", v, err, shared)
+			}
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single call but got %v", calls.Load())
+	}
+}
+
+func TestSingleFlightCancellation(t *testing.T) {
+	var g SingleFlight[string, int]
+	work := make(chan Controller, 1)
+	fn := func(c Controller) (int, error) {
+		work <- c
+		if err := c.Sleep(time.Second); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	first := NewController()
+	second := NewController().CancelAfter(Timeout(time.Hour))
+	errs := make(chan error, 2)
+	go func() {
+		_, err, _ := g.Do(first, "key", fn)
+		errs <- err
+	}()
+	shared := <-work
+	go func() {
+		_, err, _ := g.Do(second, "key", fn)
+		errs <- err
+	}()
+	time.Sleep(2 * time.Millisecond)
+
+	first.Cancel()
+	if err := <-errs; err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	if err := shared.Checkpoint(); err != nil {
+		t.Errorf("The shared work should go on while a caller waits. Got %v", err)
+	}
+	if d, ok := shared.Deadline(); !ok || time.Until(d) < 59*time.Minute {
+		t.Errorf("The shared deadline should be the remaining caller's. Got %v, %v", d, ok)
+	}
+
+	second.Cancel()
+	if err := <-errs; err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	select {
+	case <-shared.WasCancelled(nil):
+	case <-time.After(time.Second):
+		t.Error("The shared work should be cancelled once every caller is.")
+	}
+}
+
+func TestSingleFlightDeadline(t *testing.T) {
+	var g SingleFlight[string, bool]
+	c := NewController().CancelAfter(Timeout(time.Minute))
+	ok, err, _ := g.Do(c, "key", func(c Controller) (bool, error) {
+		_, ok := c.Deadline()
+		return ok, nil
+	})
+	if err != nil || !ok {
+		t.Errorf("The shared work should start with the deadline of its caller. Got %v, %v", ok, err)
+	}
+}
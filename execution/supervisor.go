@@ -0,0 +1,296 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrTooManyRestarts is returned by a Supervisor whose children failed more
+// often than its restart intensity allows.
+var ErrTooManyRestarts = errors.New("Too many restarts!")
+
+// PanicError is the error of a supervised child that panicked, be it via
+// Controller.Panic or otherwise.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Panic: %v", e.Value)
+}
+
+// Strategy specifies which children a Supervisor restarts when one of them
+// exits.
+type Strategy int
+
+const (
+	// OneForOne restarts the child that exited only.
+	OneForOne Strategy = iota
+	// OneForAll restarts every child.
+	OneForAll
+	// RestForOne restarts the child that exited and the children started
+	// after it.
+	RestForOne
+)
+
+// RestartPolicy specifies when a supervised child is restarted.
+type RestartPolicy int
+
+const (
+	// Permanent children are always restarted.
+	Permanent RestartPolicy = iota
+	// Transient children are restarted only when they fail.
+	Transient
+	// Temporary children are never restarted.
+	Temporary
+)
+
+// A Supervisor runs child workers, each under its own child of the
+// Supervisor's Controller, and restarts them according to its Strategy when
+// they exit.
+//
+// If children are restarted more than MaxRestarts times within Period, the
+// Supervisor gives up: it stops every child and returns ErrTooManyRestarts.
+// Restarts are delayed by an exponential backoff which grows with the number
+// of recent restarts.
+//
+// When the Supervisor's Controller is cancelled, the children are cancelled
+// one at a time, in reverse start order, each being waited for before the
+// next one is cancelled, with the cancellation cause of the Supervisor. For
+// that reason, the children are spawned from an intermediate child of the
+// Supervisor's Controller that is shielded from its cancellation, which would
+// otherwise reach them all at once. Deadlines, draining, pausing and the
+// watchdog interval are inherited as usual.
+type Supervisor struct {
+	// Strategy specifies which children are restarted when one exits.
+	Strategy Strategy
+	// MaxRestarts is the number of restarts allowed within Period.
+	MaxRestarts int
+	// Period is the time window over which restarts are counted.
+	Period time.Duration
+	// InitialBackoff is the delay before the first restart within Period.
+	// It doubles with every subsequent restart.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay before a restart. Zero means no cap.
+	MaxBackoff time.Duration
+	// Observer, if not nil, is notified of the ChildFailed, ChildRestarted and
+	// SupervisorGaveUp events.
+	Observer Observer
+
+	c        Controller
+	scope    Controller
+	mu       sync.Mutex
+	children []*supervised
+	started  bool
+}
+
+// supervised is a child of a Supervisor.
+type supervised struct {
+	name    string
+	restart RestartPolicy
+	run     func(Controller) error
+	current *incarnation
+}
+
+// incarnation is a single run of a supervised child.
+type incarnation struct {
+	index int
+	c     Controller
+	done  chan struct{}
+	err   error
+}
+
+// NewSupervisor returns a Supervisor whose children run under c.
+func NewSupervisor(c Controller, strategy Strategy, maxRestarts int, period time.Duration) *Supervisor {
+	return &Supervisor{
+		Strategy:    strategy,
+		MaxRestarts: maxRestarts,
+		Period:      period,
+		c:           c,
+	}
+}
+
+// Add registers a child. Children are started in the order in which they are
+// added. Add panics if the Supervisor is already running.
+func (s *Supervisor) Add(name string, restart RestartPolicy, run func(Controller) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		panic("Cannot add a child to a running Supervisor.")
+	}
+	s.children = append(s.children, &supervised{
+		name:    name,
+		restart: restart,
+		run:     run,
+	})
+}
+
+// Run starts the children and supervises them until the Supervisor's
+// Controller is cancelled, in which case the cancellation cause is returned,
+// or until the restart intensity is exceeded. It returns nil if every child
+// exits without having to be restarted.
+func (s *Supervisor) Run() error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		panic("Supervisor is already running.")
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	s.scope = s.c.spawnShielded()
+	defer s.scope.Cancel()
+	exits := make(chan *incarnation)
+	stopped := newsignalchan()
+	defer close(stopped)
+
+	var restarts []time.Time
+	running := 0
+	for i := range s.children {
+		s.start(i, exits, stopped)
+		running++
+	}
+
+	for running > 0 {
+		var inc *incarnation
+		select {
+		case inc = <-exits:
+		case <-s.c.sigKill:
+			cause := s.c.kill.cause()
+			s.stop(0, cause)
+			return cause
+		}
+		child := s.children[inc.index]
+		if child.current != inc {
+			continue // stopped by the Supervisor itself
+		}
+		child.current = nil
+		running--
+		if inc.err != nil {
+			s.emit(Event{Kind: ChildFailed, Err: inc.err, Name: child.name})
+		}
+		if !child.restarts(inc.err) {
+			continue
+		}
+
+		now := time.Now()
+		restarts = append(restarts, now)
+		for len(restarts) > 0 && now.Sub(restarts[0]) > s.Period {
+			restarts = restarts[1:]
+		}
+		if len(restarts) > s.MaxRestarts {
+			s.stop(0, ErrCancelled)
+			err := errors.Join(ErrTooManyRestarts, inc.err)
+			s.emit(Event{Kind: SupervisorGaveUp, Err: err, Name: child.name})
+			return err
+		}
+
+		first := len(s.children)
+		switch s.Strategy {
+		case OneForAll:
+			first = 0
+		case RestForOne:
+			first = inc.index
+		}
+		siblings := s.stop(first, ErrCancelled)
+		running -= len(siblings)
+
+		if err := s.c.Sleep(s.backoff(len(restarts))); err != nil {
+			s.stop(0, err)
+			return err
+		}
+		for i := range s.children {
+			if i != inc.index && !(siblings[i] && s.children[i].restart != Temporary) {
+				continue
+			}
+			s.start(i, exits, stopped)
+			running++
+			s.emit(Event{Kind: ChildRestarted, Err: inc.err, Name: s.children[i].name})
+		}
+	}
+	return nil
+}
+
+// restarts reports whether a child that exited with err has to be restarted.
+func (sc *supervised) restarts(err error) bool {
+	switch sc.restart {
+	case Permanent:
+		return true
+	case Transient:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// start runs the i-th child in a new goroutine, under a child of the
+// Supervisor's scope.
+func (s *Supervisor) start(i int, exits chan<- *incarnation, stopped <-chan struct{}) {
+	sc := s.children[i]
+	inc := &incarnation{
+		index: i,
+		c:     s.scope.Spawn(),
+		done:  newsignalchan(),
+	}
+	sc.current = inc
+	go func() {
+		var err error
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+			inc.err = inc.c.Finish(err)
+			close(inc.done)
+			select {
+			case exits <- inc:
+			case <-stopped:
+			}
+		}()
+		err = sc.run(inc.c)
+	}()
+}
+
+// stop cancels the running children starting at first with cause, in reverse
+// start order, waiting for each of them to exit. It returns the set of
+// children stopped.
+func (s *Supervisor) stop(first int, cause error) map[int]bool {
+	stopped := make(map[int]bool)
+	for i := len(s.children) - 1; i >= first; i-- {
+		inc := s.children[i].current
+		if inc == nil {
+			continue
+		}
+		s.children[i].current = nil
+		inc.c.cancel(cause)
+		<-inc.done
+		stopped[i] = true
+	}
+	return stopped
+}
+
+// backoff returns the delay before the n-th restart within the period.
+func (s *Supervisor) backoff(n int) time.Duration {
+	d := s.InitialBackoff
+	for i := 1; i < n && d > 0; i++ {
+		d *= 2
+		if s.MaxBackoff > 0 && d >= s.MaxBackoff {
+			break
+		}
+	}
+	if s.MaxBackoff > 0 && d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+	return d
+}
+
+func (s *Supervisor) emit(e Event) {
+	if s.Observer == nil {
+		return
+	}
+	e.At = time.Now()
+	s.Observer(e)
+}
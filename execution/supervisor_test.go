@@ -0,0 +1,184 @@
+package execution
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the names of the events observed.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	r.events = append(r.events, s)
+	r.mu.Unlock()
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	c := NewController()
+	s := NewSupervisor(c, OneForOne, 5, time.Second)
+	var r recorder
+	s.Observer = func(e Event) {
+		if e.Kind == ChildRestarted {
+			r.add(e.Name)
+		}
+	}
+	runs := 0
+	s.Add("flaky", Permanent, func(c Controller) error {
+		runs++
+		switch runs {
+		case 1:
+			c.Panic("boom")
+		case 2:
+			panic("boom")
+		}
+		return c.Sleep(time.Minute)
+	})
+	s.Add("steady", Permanent, func(c Controller) error {
+		r.add("steady started")
+		return c.Sleep(time.Minute)
+	})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.Cancel()
+	}()
+	if err := s.Run(); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	got := r.get()
+	slices.Sort(got)
+	want := []string{"flaky", "flaky", "steady started"}
+	if !slices.Equal(got, want) || runs != 3 {
+		t.Errorf("Expected %v and 3 runs but got %v and %v runs", want, got, runs)
+	}
+}
+
+func TestSupervisorStrategies(t *testing.T) {
+	errFailed := errors.New("failed")
+	for _, tc := range []struct {
+		strategy Strategy
+		want     []string
+	}{
+		{OneForAll, []string{"a", "b", "c"}},
+		{RestForOne, []string{"b", "c"}},
+	} {
+		c := NewController()
+		s := NewSupervisor(c, tc.strategy, 1, time.Second)
+		var r recorder
+		s.Observer = func(e Event) {
+			if e.Kind == ChildRestarted {
+				r.add(e.Name)
+			}
+		}
+		failed := false
+		worker := func(c Controller) error { return c.Sleep(time.Minute) }
+		s.Add("a", Permanent, worker)
+		s.Add("b", Transient, func(c Controller) error {
+			if !failed {
+				failed = true
+				return errFailed
+			}
+			return c.Sleep(time.Minute)
+		})
+		s.Add("c", Permanent, worker)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			c.Cancel()
+		}()
+		s.Run()
+		if got := r.get(); !slices.Equal(got, tc.want) {
+			t.Errorf("Strategy %v: expected %v to be restarted but got %v", tc.strategy, tc.want, got)
+		}
+	}
+}
+
+func TestSupervisorIntensity(t *testing.T) {
+	errFailed := errors.New("failed")
+	s := NewSupervisor(NewController(), OneForOne, 3, time.Second)
+	s.InitialBackoff = time.Millisecond
+	runs := 0
+	s.Add("failing", Transient, func(c Controller) error {
+		runs++
+		return errFailed
+	})
+	err := s.Run()
+	if !errors.Is(err, ErrTooManyRestarts) || !errors.Is(err, errFailed) {
+		t.Errorf("Expected ErrTooManyRestarts and the last failure but got: %v", err)
+	}
+	if runs != 4 {
+		t.Errorf("Expected 4 runs but got %v", runs)
+	}
+
+	s = NewSupervisor(NewController(), OneForOne, 3, time.Second)
+	s.Add("done", Transient, func(c Controller) error { return nil })
+	s.Add("once", Temporary, func(c Controller) error { return errFailed })
+	if err := s.Run(); err != nil {
+		t.Errorf("Expected the Supervisor to return once its children are done. Got %v", err)
+	}
+}
+
+func TestSupervisorShutdownOrder(t *testing.T) {
+	c := NewController()
+	s := NewSupervisor(c, OneForOne, 0, time.Second)
+	var r recorder
+	for _, name := range []string{"first", "second", "third"} {
+		s.Add(name, Permanent, func(c Controller) error {
+			c.Sleep(time.Minute)
+			time.Sleep(5 * time.Millisecond)
+			r.add(name)
+			return nil
+		})
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		c.Cancel()
+	}()
+	s.Run()
+	want := []string{"third", "second", "first"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+}
+
+func TestSupervisorInheritance(t *testing.T) {
+	deadline := Timeout(200 * time.Millisecond)
+	c := NewController().CancelAfter(deadline)
+	s := NewSupervisor(c, OneForOne, 0, time.Second)
+	seen := make(chan time.Time, 1)
+	drained := make(chan struct{})
+	s.Add("worker", Permanent, func(c Controller) error {
+		d, _ := c.Deadline()
+		seen <- d
+		<-c.Draining()
+		close(drained)
+		<-c.WasCancelled(nil)
+		return c.Checkpoint()
+	})
+	errs := make(chan error, 1)
+	go func() { errs <- s.Run() }()
+
+	if d := <-seen; !d.Equal(deadline) {
+		t.Errorf("A supervised child should inherit the deadline %v. Got %v", deadline, d)
+	}
+	c.Drain()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Error("A supervised child should be drained along with its Supervisor.")
+	}
+	if err := <-errs; err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+}
@@ -0,0 +1,253 @@
+package execution
+
+import (
+	"container/list"
+	"math"
+	"sync"
+)
+
+// background is a Controller that is never cancelled. It is used where a wait
+// must not be abandoned.
+var background = NewController()
+
+// A Mutex is a mutual exclusion lock whose Lock method can be abandoned when a
+// task is cancelled.
+//
+// It is fair: the goroutines waiting for the lock acquire it in FIFO order.
+type Mutex struct {
+	s *Semaphore
+}
+
+// NewMutex returns an unlocked Mutex.
+func NewMutex() *Mutex {
+	return &Mutex{NewSemaphore(1)}
+}
+
+// Lock locks m, blocking until it is available or until the task controlled
+// by c is cancelled, in which case the cancellation cause is returned and m
+// is not locked.
+func (m *Mutex) Lock(c Controller) error {
+	return m.s.Acquire(c, 1)
+}
+
+// TryLock tries to lock m without blocking and reports whether it succeeded.
+func (m *Mutex) TryLock() bool {
+	return m.s.TryAcquire(1)
+}
+
+// Unlock unlocks m. It panics if m is not locked.
+func (m *Mutex) Unlock() {
+	m.s.Release(1)
+}
+
+// maxReaders is the maximum number of concurrent readers of a RWMutex.
+const maxReaders = math.MaxInt32
+
+// A RWMutex is a reader/writer mutual exclusion lock whose locking methods can
+// be abandoned when a task is cancelled.
+//
+// It is fair: readers and writers acquire the lock in FIFO order. In
+// particular, a waiting writer blocks the readers that arrive after it, so
+// that writers are not starved.
+type RWMutex struct {
+	s *Semaphore
+}
+
+// NewRWMutex returns an unlocked RWMutex.
+func NewRWMutex() *RWMutex {
+	return &RWMutex{NewSemaphore(maxReaders)}
+}
+
+// Lock locks rw for writing. See Mutex.Lock.
+func (rw *RWMutex) Lock(c Controller) error {
+	return rw.s.Acquire(c, maxReaders)
+}
+
+// Unlock unlocks rw for writing.
+func (rw *RWMutex) Unlock() {
+	rw.s.Release(maxReaders)
+}
+
+// RLock locks rw for reading. See Mutex.Lock.
+func (rw *RWMutex) RLock(c Controller) error {
+	return rw.s.Acquire(c, 1)
+}
+
+// RUnlock undoes a single RLock call.
+func (rw *RWMutex) RUnlock() {
+	rw.s.Release(1)
+}
+
+// Cond is a condition variable whose Wait method can be abandoned when a task
+// is cancelled.
+//
+// Waiters are woken up in FIFO order by Signal.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L *Mutex
+
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// NewCond returns a Cond associated with l.
+func NewCond(l *Mutex) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait atomically unlocks c.L and suspends the calling goroutine until it is
+// woken up by Signal or Broadcast, or until the task controlled by ctrl is
+// cancelled, in which case the cancellation cause is returned.
+//
+// As for sync.Cond, c.L must be held when calling Wait, and is held again when
+// Wait returns, including when it returns an error.
+func (c *Cond) Wait(ctrl Controller) error {
+	ready := newsignalchan()
+	c.mu.Lock()
+	elem := c.waiters.PushBack(ready)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	var err error
+	select {
+	case <-ready:
+	case <-ctrl.sigKill:
+		err = ctrl.kill.cause()
+		c.mu.Lock()
+		select {
+		case <-ready:
+			// Woken up concurrently: pass the signal on so that it is not lost.
+			c.signal()
+		default:
+			c.waiters.Remove(elem)
+		}
+		c.mu.Unlock()
+	}
+	c.L.Lock(background)
+	return err
+}
+
+// Signal wakes up the goroutine that has been waiting on c the longest, if any.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	c.signal()
+	c.mu.Unlock()
+}
+
+// signal wakes up the first waiter. c.mu must be held.
+func (c *Cond) signal() {
+	if e := c.waiters.Front(); e != nil {
+		c.waiters.Remove(e)
+		close(e.Value.(chan struct{}))
+	}
+}
+
+// Broadcast wakes up all the goroutines waiting on c.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	for e := c.waiters.Front(); e != nil; e = c.waiters.Front() {
+		c.waiters.Remove(e)
+		close(e.Value.(chan struct{}))
+	}
+	c.mu.Unlock()
+}
+
+// A Barrier blocks a fixed number of parties until all of them have reached
+// it. It is cyclic: once released, it can be used again.
+type Barrier struct {
+	parties int
+
+	mu      sync.Mutex
+	arrived int
+	release chan struct{}
+}
+
+// NewBarrier returns a Barrier for n parties.
+func NewBarrier(n int) *Barrier {
+	return &Barrier{parties: n, release: newsignalchan()}
+}
+
+// Wait blocks until all the parties have called Wait, or until the task
+// controlled by c is cancelled.
+// In the latter case, the party withdraws from the barrier, which the other
+// parties keep on waiting at, and the cancellation cause is returned.
+func (b *Barrier) Wait(c Controller) error {
+	if err := c.Checkpoint(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	release := b.release
+	b.arrived++
+	if b.arrived == b.parties {
+		b.arrived = 0
+		b.release = newsignalchan()
+		close(release)
+		b.mu.Unlock()
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-release:
+		return nil
+	case <-c.sigKill:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		select {
+		case <-release:
+			// The barrier was passed in the meantime.
+			return nil
+		default:
+			b.arrived--
+			return c.kill.cause()
+		}
+	}
+}
+
+// A Latch is a countdown latch: it lets goroutines wait until a number of
+// events have occurred. Unlike a Barrier, it cannot be reused.
+type Latch struct {
+	mu    sync.Mutex
+	count int
+	done  chan struct{}
+}
+
+// NewLatch returns a Latch that opens after n calls to CountDown.
+func NewLatch(n int) *Latch {
+	l := &Latch{count: n, done: newsignalchan()}
+	if n <= 0 {
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown decrements the count of l, opening it when it reaches zero.
+func (l *Latch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count <= 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.done)
+	}
+}
+
+// Count returns the current count of l.
+func (l *Latch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait blocks until l opens or until the task controlled by c is cancelled,
+// in which case the cancellation cause is returned.
+func (l *Latch) Wait(c Controller) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-c.sigKill:
+		return c.kill.cause()
+	}
+}
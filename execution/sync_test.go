@@ -0,0 +1,205 @@
+package execution
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMutex(t *testing.T) {
+	m := NewMutex()
+	c := NewController()
+	counter := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := m.Lock(c); err != nil {
+					t.Error(err)
+					return
+				}
+				counter++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if counter != 5000 {
+		t.Errorf("Expected 5000 but got %v", counter)
+	}
+
+	m.Lock(c)
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if err := m.Lock(d); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	m.Unlock()
+	if !m.TryLock() {
+		t.Error("The abandoned Lock call should not hold the mutex.")
+	}
+}
+
+func TestMutexFairness(t *testing.T) {
+	m := NewMutex()
+	c := NewController()
+	m.Lock(c)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Lock(c)
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			m.Unlock()
+		}(i)
+		time.Sleep(time.Millisecond)
+	}
+	m.Unlock()
+	wg.Wait()
+	for i, v := range order {
+		if i != v {
+			t.Fatalf("Waiters should acquire the lock in FIFO order. Got %v", order)
+		}
+	}
+}
+
+func TestRWMutex(t *testing.T) {
+	rw := NewRWMutex()
+	c := NewController()
+	shared := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rw.Lock(c)
+			shared++
+			rw.Unlock()
+		}()
+		go func() {
+			defer wg.Done()
+			rw.RLock(c)
+			_ = shared
+			rw.RUnlock()
+		}()
+	}
+	wg.Wait()
+
+	rw.RLock(c)
+	rw.RLock(c)
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if err := rw.Lock(d); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+	if err := rw.Lock(c); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestCond(t *testing.T) {
+	m := NewMutex()
+	cond := NewCond(m)
+	c := NewController()
+	ready := false
+
+	done := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			m.Lock(c)
+			defer m.Unlock()
+			for !ready {
+				if err := cond.Wait(c); err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- nil
+		}()
+	}
+	time.Sleep(2 * time.Millisecond)
+	m.Lock(c)
+	ready = true
+	cond.Broadcast()
+	m.Unlock()
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Expected no error but got %v", err)
+		}
+	}
+
+	parent := NewController()
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		parent.Cancel()
+	}()
+	m.Lock(c)
+	if err := cond.Wait(parent.Spawn()); err != ErrCancelled {
+		t.Errorf("Expected: %v but got: %v", ErrCancelled, err)
+	}
+	if m.TryLock() {
+		t.Error("Wait should return with the lock held.")
+	}
+	m.Unlock()
+}
+
+func TestBarrier(t *testing.T) {
+	b := NewBarrier(3)
+	c := NewController()
+
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := b.Wait(c); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if err := b.Wait(d); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+	b.mu.Lock()
+	arrived := b.arrived
+	b.mu.Unlock()
+	if arrived != 0 {
+		t.Errorf("The cancelled party should have withdrawn. Got %v arrivals", arrived)
+	}
+}
+
+func TestLatch(t *testing.T) {
+	l := NewLatch(2)
+	c := NewController()
+
+	d := NewController().CancelAfter(Timeout(2 * time.Millisecond))
+	if err := l.Wait(d); err != ErrTimedOut {
+		t.Errorf("Expected: %v but got: %v", ErrTimedOut, err)
+	}
+
+	go func() {
+		l.CountDown()
+		l.CountDown()
+	}()
+	if err := l.Wait(c); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if l.Count() != 0 {
+		t.Errorf("Expected a count of 0 but got %v", l.Count())
+	}
+}
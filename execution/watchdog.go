@@ -0,0 +1,75 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrStalled is returned when a task was cancelled by its watchdog because it
+// did not report any progress in time. It wraps ErrTimedOut.
+var ErrStalled = fmt.Errorf("Task stalled: %w", ErrTimedOut)
+
+// CancelIfStalled will clone and alter a Controller, providing a watchdog
+// that cancels the task with ErrStalled if it does not call Heartbeat at
+// least once every interval.
+// The countdown starts right away.
+//
+// The interval is inherited by the Controllers spawned afterwards, each of them
+// having its own watchdog, unless overridden by a call to CancelIfStalled.
+// A zero interval disables the watchdog.
+// The watchdog is stopped while the task is paused.
+func (c Controller) CancelIfStalled(interval time.Duration) Controller {
+	ks := newkillswitch(c.kill.parent, false)
+	ks.watch(interval)
+	c.kill.mu.Lock()
+	deadline := c.kill.deadline
+	c.kill.mu.Unlock()
+	ks.arm(deadline)
+	c.kill = ks
+	c.sigKill = ks.sigKill
+	return c
+}
+
+// Heartbeat reports that the task is making progress, resetting the countdown
+// of its watchdog, if any.
+func (c Controller) Heartbeat() {
+	ks := c.kill
+	ks.mu.Lock()
+	ks.resetWatchdog()
+	ks.mu.Unlock()
+}
+
+// watch sets the watchdog interval of a killswitch and starts the countdown,
+// unless the task is paused.
+func (ks *killswitch) watch(interval time.Duration) {
+	paused := ks.pausedOn() != nil
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.wdTimer != nil {
+		ks.wdTimer.Stop()
+		ks.wdTimer = nil
+	}
+	ks.watchdog = interval
+	ks.wdStopped = paused
+	ks.resetWatchdog()
+}
+
+// resetWatchdog restarts the countdown of the watchdog of a killswitch.
+// ks.mu must be held.
+func (ks *killswitch) resetWatchdog() {
+	if ks.watchdog <= 0 || ks.wdStopped || ks.shielded || ks.err != nil {
+		return
+	}
+	if ks.wdTimer == nil {
+		ks.wdTimer = time.AfterFunc(ks.watchdog, func() { ks.trigger(ErrStalled, true) })
+		return
+	}
+	ks.wdTimer.Reset(ks.watchdog)
+}
+
+// interval returns the watchdog interval of a killswitch.
+func (ks *killswitch) interval() time.Duration {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	return ks.watchdog
+}
@@ -0,0 +1,70 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	c := NewController().CancelIfStalled(50 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		c.Heartbeat()
+	}
+	if err := c.Checkpoint(); err != nil {
+		t.Errorf("A task sending heartbeats should not be cancelled. Got %v", err)
+	}
+
+	select {
+	case <-c.WasCancelled(nil):
+		err := c.Checkpoint()
+		if err != ErrStalled || !errors.Is(err, ErrTimedOut) {
+			t.Errorf("Expected: %v but got: %v", ErrStalled, err)
+		}
+	case <-time.After(time.Second):
+		t.Error("The watchdog should have cancelled the stalled task.")
+	}
+}
+
+func TestWatchdogInheritance(t *testing.T) {
+	parent := NewController().CancelIfStalled(30 * time.Millisecond)
+	defer parent.Cancel()
+	child := parent.Spawn()
+	unwatched := parent.Spawn().CancelIfStalled(0)
+
+	// Keep the parent alive: only the child should be stalled.
+	stop := time.After(time.Second)
+loop:
+	for {
+		select {
+		case <-child.WasCancelled(nil):
+			break loop
+		case <-stop:
+			t.Fatal("The spawned controller should have inherited the watchdog.")
+		case <-time.After(time.Millisecond):
+			parent.Heartbeat()
+		}
+	}
+	if err := child.Checkpoint(); err != ErrStalled {
+		t.Errorf("Expected: %v but got: %v", ErrStalled, err)
+	}
+	if err := unwatched.Checkpoint(); err != nil {
+		t.Errorf("The overridden watchdog should be disabled. Got %v", err)
+	}
+}
+
+func TestWatchdogPaused(t *testing.T) {
+	parent := NewController()
+	c := parent.Spawn().CancelIfStalled(5 * time.Millisecond)
+	parent.Pause()
+	time.Sleep(15 * time.Millisecond)
+	if err := c.Checkpoint(); err != nil {
+		t.Errorf("The watchdog should be stopped while paused. Got %v", err)
+	}
+	parent.Resume()
+	c.Heartbeat()
+	if err := c.Checkpoint(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}